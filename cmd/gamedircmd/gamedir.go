@@ -0,0 +1,93 @@
+package gamedircmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sector-f/jhmod/gamedir"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	gamedirCmd.AddCommand(setCmd())
+	gamedirCmd.PersistentFlags().BoolP("verbose", "v", false, "List every directory that was looked in")
+}
+
+var gamedirCmd = &cobra.Command{
+	Use:   "gamedir",
+	Short: "Show or set the Jupiter Hell game directory",
+	Long: `Show or set the Jupiter Hell game directory.
+
+Commands that need the game's archives, such as "nvc extract", "nvc list" and
+"mod", use the game directory unless they are given a path.  It is taken from
+the ` + gamedir.EnvVar + ` environment variable, jhmod's config file, Steam's
+libraryfolders.vdf, or a few common install locations, in that order.  Use
+"jhmod gamedir set DIR" to save it in the config file.
+
+With --verbose, every place that was looked in is listed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		verbose, _ := cmd.PersistentFlags().GetBool("verbose")
+
+		finder := gamedir.DefaultFinder()
+		if verbose {
+			candidates, errs := finder.Candidates()
+			for _, err := range errs {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+			for _, c := range candidates {
+				status := "ok"
+				if err := gamedir.Validate(c.Dir); err != nil {
+					status = err.Error()
+				}
+				fmt.Fprintf(os.Stderr, "%s (from %s): %s\n", c.Dir, c.From, status)
+			}
+		}
+
+		found, err := finder.Find()
+		if err != nil {
+			return err
+		}
+		fmt.Println(found.Dir)
+		return nil
+	},
+}
+
+func setCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set DIR",
+		Short: "Save the game directory in jhmod's config file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+			if err := gamedir.Validate(dir); err != nil {
+				return err
+			}
+
+			path, err := gamedir.ConfigPath()
+			if err != nil {
+				return err
+			}
+			config, err := gamedir.ReadConfig(path)
+			if err != nil {
+				return err
+			}
+			config.GameDir = dir
+			if err := gamedir.WriteConfig(path, config); err != nil {
+				return err
+			}
+			fmt.Printf("Saved %s in %s\n", dir, path)
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func Cmd() *cobra.Command {
+	return gamedirCmd
+}
@@ -0,0 +1,288 @@
+package langcmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sector-f/jhmod/lang"
+	"github.com/sector-f/jhmod/nvc"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	langCmd.AddCommand(exportCmd())
+	langCmd.AddCommand(importCmd())
+	langCmd.AddCommand(diffCmd())
+}
+
+var langCmd = &cobra.Command{
+	Use:   "lang",
+	Short: "Work with the localization tables in data/lang",
+}
+
+// openArchive parses the archive at path. The returned file must be closed once the archive is no longer needed.
+func openArchive(path string) (nvc.Archive, *os.File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nvc.Archive{}, nil, err
+	}
+
+	archive, err := nvc.Parse(f)
+	if err != nil {
+		f.Close()
+		return nvc.Archive{}, nil, err
+	}
+	return archive, f, nil
+}
+
+// readTable reads the table for language from archive.
+func readTable(archive nvc.Archive, language string) (*lang.Table, error) {
+	data, err := archive.File(nvc.String2Hash(lang.Path(language)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", lang.Path(language), err)
+	}
+
+	table, err := lang.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", lang.Path(language), err)
+	}
+	return table, nil
+}
+
+func exportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export ARCHIVE LANGUAGE...",
+		Short: "Export localization tables for use in translation tools",
+		Long: `Export localization tables for use in translation tools.
+
+Each LANGUAGE, such as "de", is read from data/lang/LANGUAGE.csv in the
+archive and written to the output directory as LANGUAGE.po, LANGUAGE.xlf or
+LANGUAGE.csv depending on --format.  Strings that have not been translated are
+exported with an empty translation.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, _ := cmd.PersistentFlags().GetString("format")
+			outputDir, _ := cmd.PersistentFlags().GetString("output")
+			sourceLanguage, _ := cmd.PersistentFlags().GetString("source-language")
+
+			ext, ok := map[string]string{"po": ".po", "xliff": ".xlf", "csv": ".csv"}[format]
+			if !ok {
+				return fmt.Errorf("Unknown format %q (expected po, xliff or csv)", format)
+			}
+
+			archive, arcFile, err := openArchive(args[0])
+			if err != nil {
+				return err
+			}
+			defer arcFile.Close()
+
+			for _, language := range args[1:] {
+				table, err := readTable(archive, language)
+				if err != nil {
+					return err
+				}
+
+				outPath := filepath.Join(outputDir, language+ext)
+				out, err := os.Create(outPath)
+				if err != nil {
+					return err
+				}
+
+				switch format {
+				case "po":
+					err = lang.WritePO(out, table, language)
+				case "xliff":
+					err = lang.WriteXLIFF(out, table, sourceLanguage, language)
+				default:
+					err = table.Write(out)
+				}
+				if closeErr := out.Close(); err == nil {
+					err = closeErr
+				}
+				if err != nil {
+					return fmt.Errorf("Error writing %s: %w", outPath, err)
+				}
+
+				fmt.Printf("%s: %d strings, %d untranslated\n", outPath, len(table.Entries), len(table.Untranslated()))
+			}
+
+			return nil
+		},
+	}
+
+	cmd.PersistentFlags().StringP("format", "F", "po", "Output format (po, xliff or csv)")
+	cmd.PersistentFlags().StringP("output", "o", ".", "Output directory")
+	cmd.PersistentFlags().StringP("source-language", "s", "en", "Language of the keys, recorded in XLIFF files")
+
+	return cmd
+}
+
+// readTranslations reads the translations in a PO, XLIFF or CSV file, depending on its extension.
+func readTranslations(path string) ([]lang.Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".po":
+		return lang.ReadPO(f)
+	case ".xlf", ".xliff":
+		return lang.ReadXLIFF(f)
+	case ".csv":
+		table, err := lang.Parse(f)
+		if err != nil {
+			return nil, err
+		}
+		return table.Entries, nil
+	default:
+		return nil, fmt.Errorf("%s: unknown file type (expected .po, .xlf, .xliff or .csv)", path)
+	}
+}
+
+func importCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import ARCHIVE FILE...",
+		Short: "Import translated PO, XLIFF or CSV files into an archive",
+		Long: `Import translated PO, XLIFF or CSV files into an archive.
+
+The language of each FILE is taken from its name, so de.po updates
+data/lang/de.csv.  Only strings whose keys are already in the table are
+updated, and empty translations are ignored.  The archive is modified in place
+unless --output is given.
+
+Files for the same language are applied in the order given, so later files win
+where they overlap.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			outPath, _ := cmd.PersistentFlags().GetString("output")
+			if outPath == "" {
+				outPath = args[0]
+			}
+
+			editor, err := nvc.OpenEditor(args[0])
+			if err != nil {
+				return err
+			}
+			defer editor.Close()
+
+			// The tables are read through the editor so that the archive is only open once when it is replaced
+			archive := editor.Original()
+
+			// Files for the same language are applied to the same table in order, so that later files
+			// add to the changes of earlier ones instead of starting again from the original
+			tables := map[string]*lang.Table{}
+
+			changedTotal := 0
+			for _, fName := range args[1:] {
+				language := lang.LanguageOf(fName)
+
+				table, exists := tables[language]
+				if !exists {
+					table, err = readTable(archive, language)
+					if err != nil {
+						return err
+					}
+					tables[language] = table
+				}
+
+				updates, err := readTranslations(fName)
+				if err != nil {
+					return err
+				}
+
+				changed, unknown := table.Update(updates)
+				for _, key := range unknown {
+					fmt.Fprintf(os.Stderr, "Warning: %s: %q is not in %s\n", fName, key, lang.Path(language))
+				}
+				fmt.Printf("%s: updated %d strings in %s\n", fName, changed, lang.Path(language))
+
+				if changed == 0 {
+					continue
+				}
+				changedTotal += changed
+
+				if err := editor.Replace(nvc.String2Hash(lang.Path(language)), bytes.NewReader(table.Bytes())); err != nil {
+					return err
+				}
+			}
+
+			if changedTotal == 0 && outPath == args[0] {
+				return nil
+			}
+			return editor.CommitTo(outPath)
+		},
+	}
+
+	cmd.PersistentFlags().StringP("output", "o", "", "Write the patched archive to this path instead of modifying ARCHIVE")
+
+	return cmd
+}
+
+func diffCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff ARCHIVE BASE [LANGUAGE...]",
+		Short: "Compare the keys of localization tables",
+		Long: `Compare the keys of localization tables.
+
+Each LANGUAGE is compared against BASE, listing keys that are missing from it,
+keys that BASE does not have, and strings that have not been translated.  With
+no LANGUAGE, the untranslated strings in BASE are listed.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			archive, arcFile, err := openArchive(args[0])
+			if err != nil {
+				return err
+			}
+			defer arcFile.Close()
+
+			base, err := readTable(archive, args[1])
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 2 {
+				printKeys(args[1]+": untranslated", base.Untranslated())
+				return nil
+			}
+
+			for _, language := range args[2:] {
+				table, err := readTable(archive, language)
+				if err != nil {
+					return err
+				}
+
+				d := lang.Compare(base, table)
+				if d.Empty() {
+					fmt.Printf("%s: no differences from %s\n", language, args[1])
+					continue
+				}
+				printKeys(fmt.Sprintf("%s: missing (in %s only)", language, args[1]), d.Missing)
+				printKeys(fmt.Sprintf("%s: extra (not in %s)", language, args[1]), d.Extra)
+				printKeys(language+": untranslated", d.Untranslated)
+			}
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func printKeys(heading string, keys []string) {
+	if len(keys) == 0 {
+		return
+	}
+	fmt.Printf("%s (%d):\n", heading, len(keys))
+	for _, key := range keys {
+		fmt.Printf("  %q\n", key)
+	}
+}
+
+func Cmd() *cobra.Command {
+	return langCmd
+}
@@ -0,0 +1,107 @@
+package luacmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sector-f/jhmod/luac"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	luaCmd.AddCommand(luaInfoCmd())
+}
+
+var luaCmd = &cobra.Command{
+	Use:   "lua",
+	Short: "Work with Lua files",
+}
+
+func luaInfoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "info FILE",
+		Short: "Show the header, functions and constants of precompiled Lua",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			showConstants, _ := cmd.PersistentFlags().GetBool("constants")
+
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			chunk, err := luac.Parse(f)
+			if err != nil {
+				return err
+			}
+
+			h := chunk.Header
+			fmt.Printf("Chunk %s\n", args[0])
+			fmt.Printf("  Version:     %s\n", h.VersionString())
+			if h.LuaJIT {
+				fmt.Printf("  Flags:       0x%x\n", h.Flags)
+				fmt.Printf("  Stripped:    %v\n", h.Stripped)
+			} else {
+				fmt.Printf("  Format:      %d\n", h.Format)
+				if h.IntSize != 0 {
+					fmt.Printf("  int:         %d bytes\n", h.IntSize)
+					fmt.Printf("  size_t:      %d bytes\n", h.SizeTSize)
+				}
+				fmt.Printf("  Instruction: %d bytes\n", h.InstructionSize)
+				if h.IntegerSize != 0 {
+					fmt.Printf("  lua_Integer: %d bytes\n", h.IntegerSize)
+				}
+				fmt.Printf("  lua_Number:  %d bytes", h.NumberSize)
+				if h.Integral {
+					fmt.Print(" (integral)")
+				}
+				fmt.Println()
+			}
+			fmt.Printf("  Endianness:  %s\n", endianness(h.LittleEndian))
+			fmt.Println()
+
+			functions := chunk.Functions()
+			fmt.Printf("Functions (%d):\n", len(functions))
+			for _, fn := range functions {
+				vararg := ""
+				if fn.IsVararg {
+					vararg = "+"
+				}
+				fmt.Printf("  %s:%d-%d params=%d%s stack=%d instructions=%d upvalues=%d constants=%d functions=%d\n",
+					fn.Source, fn.LineDefined, fn.LastLineDefined,
+					fn.NumParams, vararg, fn.MaxStackSize,
+					fn.Instructions, fn.Upvalues, len(fn.Constants), len(fn.Functions))
+
+				if showConstants {
+					for _, k := range fn.Constants {
+						fmt.Printf("    %-8s %v\n", k.Kind, k)
+					}
+				}
+			}
+			fmt.Println()
+
+			strs := chunk.Strings()
+			fmt.Printf("Strings (%d):\n", len(strs))
+			for _, s := range strs {
+				fmt.Printf("  %q\n", s)
+			}
+
+			return nil
+		},
+	}
+	cmd.PersistentFlags().BoolP("constants", "k", false, "List the constants of each function")
+
+	return cmd
+}
+
+func endianness(little bool) string {
+	if little {
+		return "little"
+	}
+	return "big"
+}
+
+func Cmd() *cobra.Command {
+	return luaCmd
+}
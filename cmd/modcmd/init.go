@@ -0,0 +1,105 @@
+package modcmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sector-f/jhmod/gamedir"
+	"github.com/sector-f/jhmod/mod"
+	"github.com/sector-f/jhmod/nvc"
+	"github.com/spf13/cobra"
+)
+
+// openReference reads the pathlist at pathlistPath and parses the archive at arcPath.
+// The returned file must be closed once the archive is no longer needed.
+func openReference(arcPath, pathlistPath string) (nvc.Archive, *os.File, []string, error) {
+	pathFile, err := os.Open(pathlistPath)
+	if err != nil {
+		return nvc.Archive{}, nil, nil, err
+	}
+	defer pathFile.Close()
+
+	pathlist, err := nvc.ReadPathlist(pathFile)
+	if err != nil {
+		return nvc.Archive{}, nil, nil, err
+	}
+
+	arcFile, err := os.Open(arcPath)
+	if err != nil {
+		return nvc.Archive{}, nil, nil, err
+	}
+
+	archive, err := nvc.Parse(arcFile)
+	if err != nil {
+		arcFile.Close()
+		return nvc.Archive{}, nil, nil, fmt.Errorf("%s: %w", arcPath, err)
+	}
+	return archive, arcFile, pathlist, nil
+}
+
+func modInitCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "init NAME",
+		Short: "Create the folder for a new mod in the game directory",
+		Long: `Create the folder for a new mod in the game directory.
+
+The mod is created in mods/NAME with a main.lua for its code and a mod.json
+manifest that holds its name, version and dependencies.
+
+With --reference, the Lua files named in --pathlist are extracted from the
+game's core.nvc into the read-only mods/NAME/reference directory, to look up
+the tables that the mod can change.  They are not part of the mod.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reference, _ := cmd.PersistentFlags().GetBool("reference")
+			pathlistPath, _ := cmd.PersistentFlags().GetString("pathlist")
+			arcPath, _ := cmd.PersistentFlags().GetString("file")
+
+			dir, err := gameDir(cmd)
+			if err != nil {
+				return err
+			}
+			if reference && pathlistPath == "" {
+				return errors.New("A pathlist must be given with --pathlist to extract reference files")
+			}
+			if arcPath == "" {
+				arcPath = gamedir.CorePath(dir)
+			}
+
+			// Open the archive first, so that a missing archive does not leave a half made mod behind
+			var archive nvc.Archive
+			var pathlist []string
+			if reference {
+				var arcFile *os.File
+				archive, arcFile, pathlist, err = openReference(arcPath, pathlistPath)
+				if err != nil {
+					return err
+				}
+				defer arcFile.Close()
+			}
+
+			modDir, err := mod.Init(dir, args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Created %s\n", modDir)
+
+			if reference {
+				count, err := mod.ExtractReference(archive, pathlist, filepath.Join(modDir, mod.ReferenceDir))
+				if err != nil {
+					return fmt.Errorf("Failed to extract reference files: %w", err)
+				}
+				fmt.Printf("Extracted %d reference files into %s\n", count, filepath.Join(modDir, mod.ReferenceDir))
+			}
+			return nil
+		},
+	}
+	addGameDirFlag(cmd)
+	cmd.PersistentFlags().BoolP("reference", "r", false, "Extract the game's Lua files into the mod's reference directory")
+	cmd.PersistentFlags().StringP("pathlist", "p", "", "Path to pathlist file, used with --reference")
+	cmd.PersistentFlags().StringP("file", "f", "", "Path to the NVC file to extract reference files from (default GAME_DIR/core.nvc)")
+
+	return cmd
+}
@@ -0,0 +1,186 @@
+package modcmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sector-f/jhmod/mod"
+	"github.com/spf13/cobra"
+)
+
+func modInstallCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "install ZIP|DIR ...",
+		Short: "Install mods into the game directory",
+		Long: `Install mods into the game directory.
+
+Each mod is copied into mods/NAME, where NAME comes from the mod's mod.json,
+or else the folder it is in.  A zip file may hold the mod's files at its top
+level or in a single folder.  Every mod must have a main.lua.
+
+A mod is not installed if another enabled mod has a file with the same path,
+unless --ignore-conflicts is given.  A mod that jhmod installed before is only
+replaced if --replace is given.  Mods that were not installed by jhmod are
+never replaced.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			replace, _ := cmd.PersistentFlags().GetBool("replace")
+			ignoreConflicts, _ := cmd.PersistentFlags().GetBool("ignore-conflicts")
+
+			dir, err := gameDir(cmd)
+			if err != nil {
+				return err
+			}
+
+			for _, arg := range args {
+				src, err := mod.OpenSource(arg)
+				if err != nil {
+					return err
+				}
+
+				installed, err := mod.Install(dir, src, mod.InstallOptions{Replace: replace, IgnoreConflicts: ignoreConflicts})
+				src.Close()
+				if err != nil {
+					return fmt.Errorf("Failed to install %s: %w", arg, err)
+				}
+				name := installed.Name
+				if installed.Version != "" {
+					name += " " + installed.Version
+				}
+				fmt.Printf("Installed %s (%d files)\n", name, len(installed.Files))
+			}
+			return nil
+		},
+	}
+	addGameDirFlag(cmd)
+	cmd.PersistentFlags().Bool("replace", false, "Replace mods that jhmod installed before, such as with a newer version")
+	cmd.PersistentFlags().Bool("ignore-conflicts", false, "Install mods even if they have files in common with other enabled mods")
+
+	return cmd
+}
+
+func modUninstallCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "uninstall NAME ...",
+		Short: "Remove mods that were installed by jhmod",
+		Long: `Remove mods that were installed by jhmod.
+
+Only the files that jhmod installed are removed.  Anything else in the mod's
+folder, such as reference files, is left in place and listed.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := gameDir(cmd)
+			if err != nil {
+				return err
+			}
+
+			for _, name := range args {
+				left, err := mod.Uninstall(dir, name)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Uninstalled %s\n", name)
+				for _, f := range left {
+					fmt.Fprintf(os.Stderr, "Left %s, which was not installed by jhmod\n", f)
+				}
+			}
+			return nil
+		},
+	}
+	addGameDirFlag(cmd)
+
+	return cmd
+}
+
+func modListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the mods in the game directory",
+		Long: `List the mods in the game directory.
+
+Mods without a main.lua are marked, since the game cannot load them, and
+files that are in more than one enabled mod are listed as conflicts.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := gameDir(cmd)
+			if err != nil {
+				return err
+			}
+
+			mods, err := mod.List(dir)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tVERSION\tSTATE\tINSTALLED BY\tNOTES")
+			for _, m := range mods {
+				state := "enabled"
+				if !m.Enabled {
+					state = "disabled"
+				}
+				by := "hand"
+				if m.Installed != nil {
+					by = "jhmod"
+				}
+				notes := ""
+				if !m.HasMain {
+					notes = "no " + mod.MainFile
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", m.Name, m.Manifest.Version, state, by, notes)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+
+			conflicts, err := mod.Conflicts(dir)
+			if err != nil {
+				return err
+			}
+			if len(conflicts) > 0 {
+				fmt.Println()
+				fmt.Println("Conflicts:")
+				for _, c := range conflicts {
+					fmt.Printf("  %s is in %s\n", c.Path, strings.Join(c.Mods, ", "))
+				}
+			}
+			return nil
+		},
+	}
+	addGameDirFlag(cmd)
+
+	return cmd
+}
+
+// modSetEnabledCmd returns the enable command if enabled is true, and the disable command otherwise.
+func modSetEnabledCmd(enabled bool) *cobra.Command {
+	use, done, short := "enable", "Enabled", "Enable disabled mods"
+	if !enabled {
+		use, done, short = "disable", "Disabled", "Disable mods, so that the game does not load them"
+	}
+
+	cmd := &cobra.Command{
+		Use:   use + " NAME ...",
+		Short: short,
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir, err := gameDir(cmd)
+			if err != nil {
+				return err
+			}
+
+			for _, name := range args {
+				if err := mod.SetEnabled(dir, name, enabled); err != nil {
+					return fmt.Errorf("Failed to %s %s: %w", use, name, err)
+				}
+				fmt.Printf("%s %s\n", done, name)
+			}
+			return nil
+		},
+	}
+	addGameDirFlag(cmd)
+
+	return cmd
+}
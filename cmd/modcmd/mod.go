@@ -0,0 +1,39 @@
+package modcmd
+
+import (
+	"github.com/sector-f/jhmod/gamedir"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	modCmd.AddCommand(modInitCmd())
+	modCmd.AddCommand(modInstallCmd())
+	modCmd.AddCommand(modUninstallCmd())
+	modCmd.AddCommand(modListCmd())
+	modCmd.AddCommand(modSetEnabledCmd(true))
+	modCmd.AddCommand(modSetEnabledCmd(false))
+	modCmd.AddCommand(modPackCmd())
+}
+
+var modCmd = &cobra.Command{
+	Use:   "mod",
+	Short: "Create and manage mods",
+}
+
+func Cmd() *cobra.Command {
+	return modCmd
+}
+
+// addGameDirFlag adds the --game-dir flag read by gameDir.
+func addGameDirFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringP("game-dir", "g", "", "Jupiter Hell game directory, which contains core.nvc (default found automatically)")
+}
+
+// gameDir returns the game directory given with --game-dir, or else the one found by the gamedir package.
+func gameDir(cmd *cobra.Command) (string, error) {
+	dir, _ := cmd.Flags().GetString("game-dir")
+	if dir == "" {
+		return gamedir.Find()
+	}
+	return dir, nil
+}
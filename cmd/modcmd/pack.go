@@ -0,0 +1,99 @@
+package modcmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/sector-f/jhmod/mod"
+	"github.com/spf13/cobra"
+)
+
+// createFile creates the file at path and passes it to write, removing it again if write fails.
+func createFile(path string, write func(*os.File) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+func modPackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pack DIR",
+		Short: "Pack a mod into a zip file for distribution",
+		Long: `Pack a mod into a zip file for distribution.
+
+The mod is checked first: it must have a main.lua, a name and a version, and
+its Lua files must not have syntax errors.  The zip holds the mod in a folder
+named after it, with a mod.json giving its name, version, game version and
+dependencies.  The version, game version and dependencies in the mod's own
+mod.json can be overridden with flags.  The reference directory and hidden
+files are left out.
+
+Packing the same files always gives the same zip file.  With --nvc, the mod's
+files other than main.lua are also written to a .nvc archive.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := cmd.PersistentFlags()
+			outPath, _ := flags.GetString("output")
+			nvcPath, _ := flags.GetString("nvc")
+			level, _ := flags.GetInt("compress")
+
+			p, err := mod.OpenPackage(args[0])
+			if err != nil {
+				return err
+			}
+			if flags.Changed("version") {
+				p.Manifest.Version, _ = flags.GetString("version")
+			}
+			if flags.Changed("game-version") {
+				p.Manifest.GameVersion, _ = flags.GetString("game-version")
+			}
+			if flags.Changed("depends") {
+				p.Manifest.Dependencies, _ = flags.GetStringSlice("depends")
+			}
+			if flags.Changed("compress") && (level < 0 || level > 9) {
+				return errors.New("Compression level must be between 0-9")
+			} else if !flags.Changed("compress") {
+				level = -1
+			}
+
+			if problems := p.Validate(); len(problems) > 0 {
+				for _, problem := range problems {
+					fmt.Fprintln(os.Stderr, problem)
+				}
+				return fmt.Errorf("%s cannot be packed because of the problems above", args[0])
+			}
+
+			if outPath == "" {
+				outPath = p.Manifest.Name + "-" + p.Manifest.Version + ".zip"
+			}
+			if err := createFile(outPath, func(f *os.File) error { return p.WriteZip(f) }); err != nil {
+				return fmt.Errorf("Failed to write '%s': %w", outPath, err)
+			}
+			fmt.Printf("Packed %s %s into %s\n", p.Manifest.Name, p.Manifest.Version, outPath)
+
+			if nvcPath != "" {
+				if err := createFile(nvcPath, func(f *os.File) error { return p.WriteNVC(f, level) }); err != nil {
+					return fmt.Errorf("Failed to write '%s': %w", nvcPath, err)
+				}
+				fmt.Printf("Wrote %d files into %s\n", len(p.Assets()), nvcPath)
+			}
+			return nil
+		},
+	}
+	cmd.PersistentFlags().StringP("output", "o", "", "Path of the zip file to write (default NAME-VERSION.zip)")
+	cmd.PersistentFlags().String("version", "", "Version of the mod")
+	cmd.PersistentFlags().String("game-version", "", "Version of the game that the mod was made for")
+	cmd.PersistentFlags().StringSlice("depends", nil, "Names of mods that the mod needs, separated by commas")
+	cmd.PersistentFlags().String("nvc", "", "Also write the mod's files to this .nvc archive")
+	cmd.PersistentFlags().IntP("compress", "c", 0, "Compression level 0-9 for the .nvc archive (default no compression)")
+
+	return cmd
+}
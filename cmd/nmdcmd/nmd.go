@@ -0,0 +1,71 @@
+package nmdcmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sector-f/jhmod/nmd"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	nmdCmd.AddCommand(nmdInfoCmd())
+}
+
+var nmdCmd = &cobra.Command{
+	Use:   "nmd",
+	Short: "Work with model (.nmd) files",
+}
+
+func nmdInfoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "info FILE",
+		Short: "Show the header words and embedded strings of a model file",
+		Long: `Show the header words and embedded strings of a model file.
+
+The structure of model files has not been reverse-engineered yet, so this
+shows the raw words after the magic bytes (as integers and floats) and the
+printable strings in the file, such as material, bone and texture names.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			words, _ := cmd.PersistentFlags().GetInt("words")
+			minLen, _ := cmd.PersistentFlags().GetInt("min-length")
+
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			model, err := nmd.Parse(f)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Model %s\n", args[0])
+			fmt.Printf("  Size: %d bytes\n", len(model.Data))
+			fmt.Println()
+
+			fmt.Println("Header:")
+			for _, w := range model.Header(words) {
+				fmt.Printf("  0x%04x: 0x%08x %10d %g\n", w.Offset, w.Value, w.Value, w.Float())
+			}
+			fmt.Println()
+
+			fmt.Println("Strings:")
+			for _, s := range model.Strings(minLen) {
+				fmt.Printf("  0x%04x: %s\n", s.Offset, s.Value)
+			}
+
+			return nil
+		},
+	}
+	cmd.PersistentFlags().IntP("words", "w", 16, "Number of header words to show")
+	cmd.PersistentFlags().IntP("min-length", "n", 4, "Minimum length of strings to show")
+
+	return cmd
+}
+
+func Cmd() *cobra.Command {
+	return nmdCmd
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/sector-f/jhmod/nvc"
 	"github.com/spf13/cobra"
@@ -17,6 +18,8 @@ func createCommand() *cobra.Command {
 		Args:  cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			verbose, _ := cmd.PersistentFlags().GetBool("verbose")
+			dedupe, _ := cmd.PersistentFlags().GetBool("dedupe")
+			alignment, _ := cmd.PersistentFlags().GetUint32("align")
 
 			shouldCompress := false
 			cmd.Flags().Visit(func(f *pflag.Flag) {
@@ -31,6 +34,11 @@ func createCommand() *cobra.Command {
 				}
 			}
 
+			fileNames := args[1:]
+			if err := checkArchivePaths(fileNames); err != nil {
+				return err
+			}
+
 			arcFilename := args[0]
 			arcFile, err := os.Create(arcFilename)
 			if err != nil {
@@ -38,11 +46,12 @@ func createCommand() *cobra.Command {
 			}
 			defer arcFile.Close()
 
-			fileNames := args[1:]
 			writer, err := nvc.NewWriter(arcFile, uint32(len(fileNames)))
 			if err != nil {
 				return err
 			}
+			writer.Dedupe = dedupe
+			writer.Alignment = alignment
 
 			for _, fName := range fileNames {
 				if verbose {
@@ -69,12 +78,52 @@ func createCommand() *cobra.Command {
 				}
 			}
 
-			return writer.Finalize()
+			if err := writer.Finalize(); err != nil {
+				return err
+			}
+
+			if dedupe {
+				members, saved := writer.DedupeStats()
+				fmt.Fprintf(os.Stderr, "Deduplicated %d files, saving %d bytes\n", members, saved)
+			}
+
+			return nil
 		},
 	}
 
 	cmd.PersistentFlags().BoolP("verbose", "v", false, "Print the names of files to standard output")
 	cmd.PersistentFlags().IntP("compress", "c", 0, "Compression level 0-9 (where 0 is no compression, 1 is best speed, and 9 is best compression)")
+	cmd.PersistentFlags().Bool("dedupe", false, "Store files with identical contents only once")
+	cmd.PersistentFlags().Uint32("align", 0, "Start each file at an offset that is a multiple of this many bytes")
 
 	return cmd
 }
+
+// archivePath returns fName cleaned and with forward slashes, so that "./data/x.lua" and "data/x.lua" compare equal.
+// Files are still stored under the hash of their path as given, as the game looks them up by that exact string.
+func archivePath(fName string) string {
+	return filepath.ToSlash(filepath.Clean(fName))
+}
+
+// checkArchivePaths returns an error if two of fileNames refer to the same archive path,
+// or if two of them would be stored under the same hash.
+func checkArchivePaths(fileNames []string) error {
+	paths := map[string]string{}
+	hashes := map[nvc.Hash]string{}
+
+	for _, fName := range fileNames {
+		path := archivePath(fName)
+		if other, exists := paths[path]; exists {
+			return fmt.Errorf("%s and %s both refer to %s", other, fName, path)
+		}
+		paths[path] = fName
+
+		hash := nvc.String2Hash(fName)
+		if other, exists := hashes[hash]; exists {
+			return fmt.Errorf("hash collision: %s and %s both hash to %v", other, fName, hash)
+		}
+		hashes[hash] = fName
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package nvccmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/sector-f/jhmod/nvc"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// memberHash interprets arg as either a 16-digit hexadecimal hash, as printed by "nvc list", or a path within an archive.
+func memberHash(arg string) nvc.Hash {
+	if len(arg) == 16 {
+		if h, err := strconv.ParseUint(arg, 16, 64); err == nil {
+			return nvc.Hash(h)
+		}
+	}
+	return nvc.String2Hash(arg)
+}
+
+// openEditor opens arcFilename for editing, applying the --compress flag if it was given.
+func openEditor(cmd *cobra.Command, arcFilename string) (*nvc.Editor, error) {
+	shouldCompress := false
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if f.Name == "compress" {
+			shouldCompress = true
+		}
+	})
+	compressLevel, _ := cmd.PersistentFlags().GetInt("compress")
+	if shouldCompress {
+		if compressLevel < 0 || compressLevel > 9 {
+			return nil, errors.New("Compression level must be between 0-9")
+		}
+	}
+
+	editor, err := nvc.OpenEditor(arcFilename)
+	if err != nil {
+		return nil, err
+	}
+
+	if shouldCompress {
+		editor.Compress = compressLevel != 0
+		editor.Level = compressLevel
+	}
+
+	return editor, nil
+}
+
+func addCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add ARCHIVE FILE...",
+		Short: "Add files to an existing .nvc archive",
+		Long: `Add files to an existing .nvc archive.
+
+Files are stored under their paths as given, so they should be relative to the
+directory containing "data".  New files are compressed if the archive already
+contains compressed files, unless --compress is given.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verbose, _ := cmd.PersistentFlags().GetBool("verbose")
+
+			fileNames := args[1:]
+			if err := checkArchivePaths(fileNames); err != nil {
+				return err
+			}
+
+			editor, err := openEditor(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			defer editor.Close()
+
+			for _, fName := range fileNames {
+				if verbose {
+					fmt.Println(fName)
+				}
+
+				file, err := os.Open(fName)
+				if err != nil {
+					return err
+				}
+
+				err = editor.Add(fName, file)
+				file.Close()
+				if err != nil {
+					return fmt.Errorf("Error adding %s: %w", fName, err)
+				}
+			}
+
+			return editor.Commit()
+		},
+	}
+
+	cmd.PersistentFlags().BoolP("verbose", "v", false, "Print the names of files to standard output")
+	cmd.PersistentFlags().IntP("compress", "c", 0, "Compression level 0-9 (where 0 is no compression, 1 is best speed, and 9 is best compression)")
+
+	return cmd
+}
+
+func rmCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rm ARCHIVE PATH|HASH...",
+		Short: "Remove files from a .nvc archive",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			editor, err := nvc.OpenEditor(args[0])
+			if err != nil {
+				return err
+			}
+			defer editor.Close()
+
+			for _, arg := range args[1:] {
+				if err := editor.Remove(memberHash(arg)); err != nil {
+					return fmt.Errorf("Error removing %s: %w", arg, err)
+				}
+			}
+
+			return editor.Commit()
+		},
+	}
+
+	return cmd
+}
+
+func replaceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replace ARCHIVE PATH|HASH FILE",
+		Short: "Replace the contents of a file in a .nvc archive",
+		Long: `Replace the contents of a file in a .nvc archive.
+
+The replaced file keeps its original compression; --compress only sets the
+compression level used.  If the archive lists the file more than once, every
+entry is replaced.  Encrypted files cannot be replaced.`,
+		Args: cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			editor, err := openEditor(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			defer editor.Close()
+
+			file, err := os.Open(args[2])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			if err := editor.Replace(memberHash(args[1]), file); err != nil {
+				return fmt.Errorf("Error replacing %s: %w", args[1], err)
+			}
+
+			return editor.Commit()
+		},
+	}
+
+	cmd.PersistentFlags().IntP("compress", "c", 0, "Compression level 0-9 (where 0 is no compression, 1 is best speed, and 9 is best compression)")
+
+	return cmd
+}
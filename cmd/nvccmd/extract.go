@@ -1,11 +1,11 @@
 package nvccmd
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
 
+	"github.com/sector-f/jhmod/filetype"
 	"github.com/sector-f/jhmod/nvc"
 	"github.com/spf13/cobra"
 )
@@ -21,25 +21,21 @@ func extractCmd() *cobra.Command {
 			extractUnknown, _ := cmd.PersistentFlags().GetBool("unknown")
 			verbose, _ := cmd.PersistentFlags().GetBool("verbose")
 
-			pathlist := []string{}
-			if pathFilename != "" {
-				pathFile, err := os.Open(pathFilename)
-				if err != nil {
-					return err
-				}
-				defer pathFile.Close()
-
-				scanner := bufio.NewScanner(pathFile)
-				for scanner.Scan() {
-					pathlist = append(pathlist, scanner.Text())
-				}
+			pathlist, err := readPathlist(pathFilename)
+			if err != nil {
+				return err
+			}
+
+			arcFilename, err = defaultArchive(arcFilename)
+			if err != nil {
+				return err
 			}
 
 			return extractNVC(arcFilename, pathlist, outputDir, extractUnknown, verbose)
 		},
 	}
 
-	cmd.PersistentFlags().StringP("file", "f", "", "Path to NVC file")
+	cmd.PersistentFlags().StringP("file", "f", "", "Path to NVC file (default core.nvc in the game directory)")
 	cmd.PersistentFlags().StringP("pathlist", "p", "", "Path to pathlist file")
 	cmd.PersistentFlags().StringP("output", "o", "", "Output directory")
 	cmd.PersistentFlags().BoolP("unknown", "u", false, "Additionally files which are not named in the pathlist file")
@@ -48,6 +44,21 @@ func extractCmd() *cobra.Command {
 	return cmd
 }
 
+// readPathlist reads the paths in a pathlist file, one per line. An empty filename gives an empty list.
+func readPathlist(filename string) ([]string, error) {
+	if filename == "" {
+		return []string{}, nil
+	}
+
+	pathFile, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer pathFile.Close()
+
+	return nvc.ReadPathlist(pathFile)
+}
+
 func extractNVC(arcPath string, pathlist []string, outputDirectory string, extractUnknown bool, verbose bool) error {
 	arcFile, err := os.Open(arcPath)
 	if err != nil {
@@ -66,22 +77,34 @@ func extractNVC(arcPath string, pathlist []string, outputDirectory string, extra
 		return err
 	}
 
+	for _, hash := range archive.Duplicates {
+		fmt.Fprintf(os.Stderr, "Warning: %v appears %d times in the table of contents; only the first will be extracted\n", hash, len(archive.EntriesFor(hash)))
+	}
+
 	extractedCount := 0
+	extractedHashes := map[nvc.Hash]struct{}{}
+
+	for _, entry := range archive.Entries {
+		hash := entry.Hash
+		if _, done := extractedHashes[hash]; done {
+			continue
+		}
 
-	for _, hash := range archive.EntryOrder {
 		path, exists := hashedPathlist[hash]
 		if extractUnknown || exists {
-			data, err := archive.File(hash)
+			extractedHashes[hash] = struct{}{}
+
+			data, err := archive.ReadEntry(entry)
 			if err != nil {
 				fmt.Fprintln(os.Stderr, err)
 				continue
 			}
 
 			if !exists { // If the path wasn't in the pathlist, make up a name using the hash
-				ftype := getFiletype(data)
+				ftype := filetype.Detect(data)
 
 				// All the other paths start in the data directory, so do the same here
-				path = filepath.Join("data", ftype.dirName, hash.String()+ftype.ext)
+				path = filepath.Join("data", ftype.Dir, hash.String()+ftype.Ext)
 			}
 
 			outputPath := filepath.Join(outputDirectory, path)
@@ -116,35 +139,3 @@ func extractNVC(arcPath string, pathlist []string, outputDirectory string, extra
 	fmt.Printf("Extracted %d files\n", extractedCount)
 	return nil
 }
-
-// filetype is used to specify the directory name and file extension for unknown files
-type filetype struct {
-	dirName string
-	ext     string
-}
-
-// Declaring this as package-scoped makes more sense than re-declaring it on every iteration
-var filetypes = map[string]filetype{
-	"\x89PNG":          {"unknown_png", ".png"},
-	"nmf1":             {"unknown_nmd", ".nmd"},
-	"OggS":             {"unknown_ogg", ".ogg"},
-	"RIFF":             {"unknown_wav", ".wav"},
-	"\x03\x02\x23\x07": {"unknown_spirv", ".spirv"},
-}
-
-// TODO: add some tests for this?
-// See https://github.com/sector-f/jhmod/issues/18
-func getFiletype(f []byte) filetype {
-	// Default values that get overwritten if possible
-	fType := filetype{"unknown", ".unknown"}
-	if len(f) < 4 {
-		return fType
-	}
-
-	magicBytes := string(f[:4])
-	if ft, exists := filetypes[magicBytes]; exists {
-		fType = ft
-	}
-
-	return fType
-}
@@ -3,18 +3,53 @@ package nvccmd
 import (
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/sector-f/jhmod/filetype"
 	"github.com/sector-f/jhmod/nvc"
 	"github.com/spf13/cobra"
 )
 
+func init() {
+	listCmd.PersistentFlags().BoolP("types", "t", false, "Show the type of each file, detected from its contents")
+	listCmd.PersistentFlags().StringP("pathlist", "p", "", "Path to pathlist file, used to show the paths of files")
+}
+
 var listCmd = &cobra.Command{
-	Use:   "list FILE",
+	Use:   "list [FILE]",
 	Short: "Manipulate nvc files",
-	Long:  `based off jh_extract.py`,
-	Args:  cobra.ExactArgs(1),
+	Long: `based off jh_extract.py
+
+If FILE is not given, core.nvc in the game directory is listed.
+
+Lua files, either named .lua in the pathlist or detected from their contents,
+are marked with lua=source or lua=bytecode.`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		reader, openErr := os.Open(args[0])
+		showTypes, _ := cmd.PersistentFlags().GetBool("types")
+		pathFilename, _ := cmd.PersistentFlags().GetString("pathlist")
+
+		pathlist, err := readPathlist(pathFilename)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		paths := map[nvc.Hash]string{}
+		for _, p := range pathlist {
+			paths[nvc.String2Hash(p)] = p
+		}
+
+		arcFilename := ""
+		if len(args) > 0 {
+			arcFilename = args[0]
+		}
+		arcFilename, err = defaultArchive(arcFilename)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		reader, openErr := os.Open(arcFilename)
 		if openErr != nil {
 			fmt.Fprintln(os.Stderr, openErr)
 			os.Exit(1)
@@ -27,9 +62,46 @@ var listCmd = &cobra.Command{
 			os.Exit(1)
 		}
 
-		for _, hash := range archive.EntryOrder {
-			entry, _ := archive.Entries[hash]
-			fmt.Println(entry)
+		for _, entry := range archive.Entries {
+			line := entry.String()
+
+			path, named := paths[entry.Hash]
+			if named {
+				line += " path=" + path
+			}
+
+			// Contents are only read when needed, since it means decompressing the member
+			if showTypes || strings.HasSuffix(path, ".lua") {
+				typ := filetype.Type{Name: "unreadable"}
+				if data, err := archive.ReadEntry(entry); err == nil {
+					typ = filetype.Detect(data)
+				}
+
+				if showTypes {
+					line += " type=" + typ.Name
+				}
+				if kind := luaKind(typ); kind != "" {
+					line += " lua=" + kind
+				}
+			}
+
+			fmt.Println(line)
+		}
+
+		for _, hash := range archive.Duplicates {
+			fmt.Fprintf(os.Stderr, "Warning: %v appears %d times in the table of contents\n", hash, len(archive.EntriesFor(hash)))
 		}
 	},
 }
+
+// luaKind returns "source" or "bytecode" for Lua files of type t, and "" for anything else.
+func luaKind(t filetype.Type) string {
+	switch t {
+	case filetype.Lua:
+		return "source"
+	case filetype.LuaByte, filetype.LuaJIT:
+		return "bytecode"
+	default:
+		return ""
+	}
+}
@@ -1,6 +1,10 @@
 package nvccmd
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/sector-f/jhmod/gamedir"
 	"github.com/spf13/cobra"
 )
 
@@ -14,8 +18,28 @@ func init() {
 	nvcCmd.AddCommand(extractCmd())
 	nvcCmd.AddCommand(pathlistCmd)
 	nvcCmd.AddCommand(createCommand())
+	nvcCmd.AddCommand(verifyCmd())
+	nvcCmd.AddCommand(statsCmd())
+	nvcCmd.AddCommand(addCmd())
+	nvcCmd.AddCommand(rmCmd())
+	nvcCmd.AddCommand(replaceCmd())
 }
 
 func Cmd() *cobra.Command {
 	return nvcCmd
 }
+
+// defaultArchive returns path, or if it is empty, the path of core.nvc in the game directory.
+func defaultArchive(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+
+	dir, err := gamedir.Find()
+	if err != nil {
+		return "", err
+	}
+	path = gamedir.CorePath(dir)
+	fmt.Fprintf(os.Stderr, "Using %s\n", path)
+	return path, nil
+}
@@ -0,0 +1,211 @@
+package nvccmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/sector-f/jhmod/filetype"
+	"github.com/sector-f/jhmod/nvc"
+	"github.com/spf13/cobra"
+)
+
+func statsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats FILE",
+		Short: "Show statistics about a .nvc file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			top, _ := cmd.PersistentFlags().GetInt("top")
+			if top < 0 {
+				return errors.New("--top must not be negative")
+			}
+
+			arcFile, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer arcFile.Close()
+
+			archive, err := nvc.Parse(arcFile)
+			if err != nil {
+				return err
+			}
+
+			layout, err := archive.Layout()
+			if err != nil {
+				return err
+			}
+
+			// Detect the file types during verification so that every entry is only decompressed once
+			types := map[int]string{}
+			result, err := archive.VerifyEach(func(i int, data []byte) {
+				types[i] = filetype.Detect(data).Name
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Entries:       %d", len(archive.Entries))
+			if len(archive.Duplicates) > 0 {
+				fmt.Printf(" (%d duplicated hashes)", len(archive.Duplicates))
+			}
+			fmt.Println()
+			fmt.Printf("Archive size:  %d bytes\n", layout.Size)
+			fmt.Printf("Header size:   %d bytes (table of contents is %d bytes)\n", layout.HeaderLength, layout.TocLength)
+			printLayout(layout)
+			if result.Shared > 0 {
+				fmt.Printf("Shared data:   %d entries point at the data of another entry, saving %d bytes\n", result.Shared, result.SharedLength)
+			}
+			fmt.Println()
+
+			printSizes(archive, types)
+			fmt.Println()
+			printFlags(archive)
+			fmt.Println()
+			printLargest(archive, top)
+
+			return nil
+		},
+	}
+
+	cmd.PersistentFlags().IntP("top", "n", 10, "Number of largest entries to show")
+
+	return cmd
+}
+
+// sizeTotals accumulates the sizes of a group of entries.
+type sizeTotals struct {
+	count  int
+	raw    uint64
+	stored uint64
+}
+
+// add adds e to t. If shared is true, e's data is also used by an earlier entry, so its stored size is not counted again.
+func (t *sizeTotals) add(e nvc.TocEntry, shared bool) {
+	t.count++
+	t.raw += uint64(e.RawLength)
+	if !shared {
+		t.stored += uint64(e.Length)
+	}
+}
+
+// ratio returns the stored size as a percentage of the raw size.
+func (t sizeTotals) ratio() float64 {
+	if t.raw == 0 {
+		return 100
+	}
+	return 100 * float64(t.stored) / float64(t.raw)
+}
+
+// printSizes prints the raw and stored sizes of the archive's members, overall and by file type.
+// types maps the index of each readable entry to the name of its file type.
+func printSizes(archive nvc.Archive, types map[int]string) {
+	total := sizeTotals{}
+	byType := map[string]*sizeTotals{}
+	// Data shared by several entries takes up space in the archive only once
+	stored := map[nvc.TocEntry]bool{}
+
+	for i, e := range archive.Entries {
+		key := e
+		key.Hash = 0
+		shared := stored[key] && e.Length > 0
+		stored[key] = true
+
+		total.add(e, shared)
+
+		typeName, ok := types[i]
+		if !ok {
+			typeName = "unreadable"
+		}
+
+		if _, exists := byType[typeName]; !exists {
+			byType[typeName] = &sizeTotals{}
+		}
+		byType[typeName].add(e, shared)
+	}
+
+	typeNames := []string{}
+	for name := range byType {
+		typeNames = append(typeNames, name)
+	}
+	sort.Slice(typeNames, func(i, j int) bool {
+		return byType[typeNames[i]].raw > byType[typeNames[j]].raw
+	})
+
+	fmt.Printf("%-12s %8s %14s %14s %7s\n", "Type", "Entries", "Raw bytes", "Stored bytes", "Ratio")
+	for _, name := range typeNames {
+		t := byType[name]
+		fmt.Printf("%-12s %8d %14d %14d %6.1f%%\n", name, t.count, t.raw, t.stored, t.ratio())
+	}
+	fmt.Printf("%-12s %8d %14d %14d %6.1f%%\n", "total", total.count, total.raw, total.stored, total.ratio())
+}
+
+// printFlags prints the number of entries with each EntryFlags value.
+func printFlags(archive nvc.Archive) {
+	counts := map[nvc.EntryFlags]int{}
+	for _, e := range archive.Entries {
+		counts[e.Flags]++
+	}
+
+	flags := []nvc.EntryFlags{}
+	for f := range counts {
+		flags = append(flags, f)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i] < flags[j] })
+
+	fmt.Println("Flags:")
+	for _, f := range flags {
+		fmt.Printf("  %-20s %d\n", flagName(f), counts[f])
+	}
+}
+
+// flagName returns a description of f.
+func flagName(f nvc.EntryFlags) string {
+	switch f {
+	case nvc.EntryFlagNoCompression:
+		return "0 (uncompressed)"
+	case nvc.EntryFlagZlibCompression:
+		return "1 (zlib)"
+	case nvc.EntryFlagEncrypted:
+		return "3 (encrypted)"
+	default:
+		return fmt.Sprintf("%d (unknown)", f)
+	}
+}
+
+// printLargest prints the n entries with the largest raw size.
+func printLargest(archive nvc.Archive, n int) {
+	sorted := make([]nvc.TocEntry, len(archive.Entries))
+	copy(sorted, archive.Entries)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].RawLength > sorted[j].RawLength })
+
+	if n > len(sorted) {
+		n = len(sorted)
+	}
+
+	fmt.Println("Largest entries:")
+	for _, e := range sorted[:n] {
+		fmt.Printf("  %v\n", e)
+	}
+}
+
+// printLayout prints the alignment and padding of an archive's members.
+func printLayout(layout nvc.Layout) {
+	fmt.Printf("Alignment:     %d bytes\n", layout.Alignment)
+
+	largest := uint32(0)
+	for _, g := range layout.Gaps {
+		if g.Length > largest {
+			largest = g.Length
+		}
+	}
+	fmt.Printf("Padding:       %d bytes in %d gaps (largest %d bytes)\n", layout.Padding(), len(layout.Gaps), largest)
+
+	if len(layout.Gaps) == 0 {
+		fmt.Println("               members are packed back-to-back")
+	} else if layout.AlignmentPadding() {
+		fmt.Printf("               gaps are consistent with %d-byte alignment\n", layout.Alignment)
+	}
+}
@@ -0,0 +1,50 @@
+package nvccmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sector-f/jhmod/nvc"
+	"github.com/spf13/cobra"
+)
+
+func verifyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify FILE",
+		Short: "Check that every member of a .nvc file can be read",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			arcFile, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer arcFile.Close()
+
+			archive, err := nvc.Parse(arcFile)
+			if err != nil {
+				return err
+			}
+
+			result, err := archive.Verify()
+			if err != nil {
+				return err
+			}
+
+			for _, hash := range archive.Duplicates {
+				fmt.Fprintf(os.Stderr, "Warning: %v appears %d times in the table of contents\n", hash, len(archive.EntriesFor(hash)))
+			}
+
+			for _, problem := range result.Problems {
+				fmt.Fprintln(os.Stderr, problem)
+			}
+
+			fmt.Printf("Checked %d entries (%d sharing data with another entry, saving %d bytes)\n", len(archive.Entries), result.Shared, result.SharedLength)
+			if len(result.Problems) > 0 {
+				return fmt.Errorf("found %d problems", len(result.Problems))
+			}
+			return nil
+		},
+	}
+
+	return cmd
+}
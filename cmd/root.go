@@ -4,8 +4,14 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/sector-f/jhmod/cmd/gamedircmd"
+	"github.com/sector-f/jhmod/cmd/langcmd"
+	"github.com/sector-f/jhmod/cmd/luacmd"
+	"github.com/sector-f/jhmod/cmd/modcmd"
+	"github.com/sector-f/jhmod/cmd/nmdcmd"
 	"github.com/sector-f/jhmod/cmd/nvccmd"
 	"github.com/sector-f/jhmod/cmd/savecmd"
+	"github.com/sector-f/jhmod/cmd/spirvcmd"
 	"github.com/spf13/cobra"
 )
 
@@ -18,6 +24,12 @@ var rootCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(nvccmd.Cmd())
 	rootCmd.AddCommand(savecmd.Cmd())
+	rootCmd.AddCommand(nmdcmd.Cmd())
+	rootCmd.AddCommand(spirvcmd.Cmd())
+	rootCmd.AddCommand(luacmd.Cmd())
+	rootCmd.AddCommand(langcmd.Cmd())
+	rootCmd.AddCommand(modcmd.Cmd())
+	rootCmd.AddCommand(gamedircmd.Cmd())
 	rootCmd.AddCommand(unzlibCommand())
 	rootCmd.AddCommand(zlibCommand())
 }
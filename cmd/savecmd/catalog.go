@@ -0,0 +1,230 @@
+package savecmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sector-f/jhmod/savecatalog"
+	"github.com/sector-f/jhmod/savefile"
+	"github.com/spf13/cobra"
+)
+
+func saveCatalogCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "catalog DIR|FILE ...",
+		Short: "Index the game mode, level and seed of save files",
+		Long: `Index the game mode, level and seed of save files.
+
+Directories are searched for save files matching --pattern, and each one is
+added to the catalog file given by --catalog.  Files that are unchanged since
+they were last added are not read again, and files that no longer exist are
+removed.  The catalog is a text file with one line of JSON per save.
+
+Use "save catalog find" to search the catalog and "save catalog seeds" to
+list or export its seeds.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			catalogPath := catalogFlag(cmd)
+			pattern, _ := cmd.PersistentFlags().GetString("pattern")
+
+			c, err := savecatalog.Load(catalogPath)
+			if err != nil {
+				return fmt.Errorf("Failed to read catalog '%s': %w", catalogPath, err)
+			}
+
+			files, err := expandInputs(args, pattern)
+			if err != nil {
+				return err
+			}
+
+			added, failed := 0, 0
+			for _, file := range files {
+				path, err := filepath.Abs(file)
+				if err != nil {
+					return err
+				}
+				info, err := os.Stat(path)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+					failed++
+					continue
+				}
+				if c.Current(path, info) {
+					continue
+				}
+
+				save, err := parseFile(path, savefile.ParseHeader)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+					failed++
+					continue
+				}
+				c.Add(savecatalog.NewEntry(path, info, save))
+				added++
+			}
+			removed := c.Prune()
+
+			if err := c.Save(catalogPath); err != nil {
+				return fmt.Errorf("Failed to write catalog '%s': %w", catalogPath, err)
+			}
+			fmt.Fprintf(os.Stderr, "Catalog has %d saves: %d added or updated, %d removed, %d could not be read\n",
+				c.Len(), added, removed, failed)
+			return nil
+		},
+	}
+	cmd.PersistentFlags().StringP("catalog", "c", "catalog.jsonl", "Path of the catalog file")
+	cmd.PersistentFlags().StringP("pattern", "p", "*.sav", "File name pattern used when searching directories")
+
+	cmd.AddCommand(saveCatalogFindCmd())
+	cmd.AddCommand(saveCatalogSeedsCmd())
+
+	return cmd
+}
+
+// addQueryFlags adds the flags read by queryFromFlags.
+func addQueryFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Uint32P("seed", "s", 0, "Only include saves with this seed")
+	cmd.PersistentFlags().StringP("mode", "m", "", "Only include saves in this game mode")
+	cmd.PersistentFlags().StringP("level", "l", "", "Only include saves on this level")
+	cmd.PersistentFlags().StringP("name", "n", "", "Only include saves with this player name")
+}
+
+// queryFromFlags builds a catalog query from the flags added by addQueryFlags.
+func queryFromFlags(cmd *cobra.Command) savecatalog.Query {
+	flags := cmd.PersistentFlags()
+	q := savecatalog.Query{}
+	if flags.Changed("seed") {
+		seed, _ := flags.GetUint32("seed")
+		q.Seed = &seed
+	}
+	q.GameMode, _ = flags.GetString("mode")
+	q.CurrentLevel, _ = flags.GetString("level")
+	q.PlayerName, _ = flags.GetString("name")
+	return q
+}
+
+// catalogFlag returns the value of the --catalog flag, which the subcommands of "save catalog" inherit from it.
+func catalogFlag(cmd *cobra.Command) string {
+	for c := cmd; c != nil; c = c.Parent() {
+		if f := c.PersistentFlags().Lookup("catalog"); f != nil {
+			return f.Value.String()
+		}
+	}
+	return ""
+}
+
+// loadCatalog reads the catalog named by the --catalog flag, which must exist.
+func loadCatalog(cmd *cobra.Command) (*savecatalog.Catalog, error) {
+	catalogPath := catalogFlag(cmd)
+	if _, err := os.Stat(catalogPath); err != nil {
+		return nil, fmt.Errorf("Failed to read catalog: %w", err)
+	}
+	c, err := savecatalog.Load(catalogPath)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read catalog '%s': %w", catalogPath, err)
+	}
+	return c, nil
+}
+
+func saveCatalogFindCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "find",
+		Short: "List the saves in a catalog, such as all runs on a seed",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			asJSON, _ := cmd.PersistentFlags().GetBool("json")
+
+			c, err := loadCatalog(cmd)
+			if err != nil {
+				return err
+			}
+			entries := c.Find(queryFromFlags(cmd))
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				for _, e := range entries {
+					if err := enc.Encode(e); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "MODE\tSEED\tLEVEL\tNAME\tMODIFIED\tFILE")
+			for _, e := range entries {
+				fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n",
+					e.GameMode, e.Seed, e.CurrentLevel, e.PlayerName, e.ModTime.Format("2006-01-02 15:04"), e.File)
+			}
+			return w.Flush()
+		},
+	}
+	addQueryFlags(cmd)
+	cmd.PersistentFlags().Bool("json", false, "Print one line of JSON per save")
+
+	return cmd
+}
+
+func saveCatalogSeedsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "seeds",
+		Short: "List or export the seeds in a catalog",
+		Long: `List or export the seeds in a catalog.
+
+Each game mode and seed is listed once, with the number of saves and the
+levels they were made on.  The json and csv formats are meant for sharing
+seeds for challenge runs.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format, _ := cmd.PersistentFlags().GetString("format")
+
+			c, err := loadCatalog(cmd)
+			if err != nil {
+				return err
+			}
+			seeds := savecatalog.Seeds(c.Find(queryFromFlags(cmd)))
+
+			switch format {
+			case "text":
+				w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+				fmt.Fprintln(w, "MODE\tSEED\tSAVES\tLEVELS")
+				for _, s := range seeds {
+					fmt.Fprintf(w, "%s\t%d\t%d\t%s\n", s.GameMode, s.Seed, s.Saves, strings.Join(s.Levels, ", "))
+				}
+				return w.Flush()
+
+			case "json":
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(seeds)
+
+			case "csv":
+				w := csv.NewWriter(os.Stdout)
+				w.Write([]string{"game_mode", "seed", "saves", "levels"})
+				for _, s := range seeds {
+					w.Write([]string{
+						s.GameMode,
+						strconv.FormatUint(uint64(s.Seed), 10),
+						strconv.Itoa(s.Saves),
+						strings.Join(s.Levels, ";"),
+					})
+				}
+				w.Flush()
+				return w.Error()
+
+			default:
+				return fmt.Errorf("Unknown format '%s' (expected text, json or csv)", format)
+			}
+		},
+	}
+	addQueryFlags(cmd)
+	cmd.PersistentFlags().StringP("format", "f", "text", "Output format: text, json or csv")
+
+	return cmd
+}
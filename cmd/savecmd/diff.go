@@ -0,0 +1,110 @@
+package savecmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sector-f/jhmod/savefile"
+	"github.com/spf13/cobra"
+)
+
+// rowOf returns the 16-byte row of data starting at offset, which may be short or empty past the end of data.
+func rowOf(data []byte, offset int) []byte {
+	if offset >= len(data) {
+		return nil
+	}
+	end := offset + 16
+	if end > len(data) {
+		end = len(data)
+	}
+	return data[offset:end]
+}
+
+// printByteDiff prints the rows of a and b that cover r, marking rows from a with "-" and rows from b with "+".
+func printByteDiff(a, b []byte, r savefile.ByteRange) {
+	for row := r.Offset - r.Offset%16; row < r.End(); row += 16 {
+		rowA, rowB := rowOf(a, row), rowOf(b, row)
+		if string(rowA) == string(rowB) {
+			fmt.Printf("  %s\n", hexRow(row, rowA))
+			continue
+		}
+		if len(rowA) > 0 {
+			fmt.Printf("- %s\n", hexRow(row, rowA))
+		}
+		if len(rowB) > 0 {
+			fmt.Printf("+ %s\n", hexRow(row, rowB))
+		}
+	}
+}
+
+func saveDiffCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff A B",
+		Short: "Compare two save files",
+		Long: `Compare two save files.
+
+Decoded fields that differ are listed first, followed by a hex dump of the
+parts of the decompressed saves that differ, labelled with the region of the
+save that they are in.  Rows from A are marked with "-" and rows from B with
+"+".  The exit status is 0 if the saves are the same, 1 if they differ, and 2
+if either could not be read.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			gap, _ := cmd.PersistentFlags().GetInt("gap")
+			maxRanges, _ := cmd.PersistentFlags().GetInt("max")
+
+			saves := [2]savefile.Save{}
+			payloads := [2][]byte{}
+			for i, p := range args {
+				save, err := readSave(p)
+				if err == nil {
+					payloads[i], err = save.Payload()
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to read '%s': %v\n", p, err)
+					os.Exit(2)
+				}
+				saves[i] = save
+			}
+
+			changes := savefile.CompareFields(saves[0], saves[1])
+			for _, c := range changes {
+				fmt.Printf("%s: %v -> %v\n", c.Field, c.A, c.B)
+			}
+
+			ranges := savefile.DiffBytes(payloads[0], payloads[1], gap)
+			if len(payloads[0]) != len(payloads[1]) {
+				fmt.Printf("Size: %d -> %d bytes\n", len(payloads[0]), len(payloads[1]))
+			}
+
+			shown := 0
+			for i, r := range ranges {
+				first, last := savefile.Region(r.Offset), savefile.Region(r.End()-1)
+				if first == last && (first == "Version" || first == "Seed") {
+					continue // Already listed above, and there is nothing more to see in the bytes
+				}
+				if maxRanges > 0 && shown == maxRanges {
+					fmt.Printf("\n... and up to %d more differences\n", len(ranges)-i)
+					break
+				}
+				shown++
+
+				region := first
+				if first != last {
+					region = first + " to " + last
+				}
+				fmt.Println()
+				fmt.Printf("%s at 0x%x (%d bytes):\n", region, r.Offset, r.Length)
+				printByteDiff(payloads[0], payloads[1], r)
+			}
+
+			if len(changes) > 0 || len(ranges) > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.PersistentFlags().Int("gap", 8, "Merge differences separated by fewer than this many equal bytes")
+	cmd.PersistentFlags().IntP("max", "n", 50, "Show at most this many differences (0 for all)")
+
+	return cmd
+}
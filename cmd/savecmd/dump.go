@@ -0,0 +1,93 @@
+package savecmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/sector-f/jhmod/savefile"
+	"github.com/spf13/cobra"
+)
+
+func saveDumpCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dump FILE",
+		Short: "Show every field of a save file, including the parts that are not understood",
+		Long: `Show every field of a save file, including the parts that are not understood.
+
+With --json, the save is printed as JSON with the unknown regions as
+hexadecimal strings.  "jhmod save load" turns the JSON back into a save file.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			asJSON, _ := cmd.PersistentFlags().GetBool("json")
+
+			save, err := readSave(args[0])
+			if err != nil {
+				return err
+			}
+
+			for _, warning := range save.Warnings {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", warning)
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(save)
+			}
+
+			fmt.Printf("Savefile %s\n", args[0])
+			fmt.Printf("  Version:   0x%x\n", save.Version)
+			fmt.Printf("  Game mode: %s\n", save.GameMode)
+			fmt.Printf("  Name:      %s\n", save.PlayerName)
+			fmt.Printf("  Level:     %s\n", save.CurrentLevel)
+			fmt.Printf("  Seed:      %v\n", save.Seed)
+			for _, span := range save.Unknown {
+				fmt.Println()
+				fmt.Printf("Unknown %s at 0x%x (%d bytes):\n", span.Name, span.Offset, len(span.Data))
+				hexDump(os.Stdout, span.Offset, span.Data, -1)
+			}
+
+			return nil
+		},
+	}
+	cmd.PersistentFlags().Bool("json", false, "Print the save as JSON")
+
+	return cmd
+}
+
+func saveLoadCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "load FILE.json",
+		Short: "Build a save file from JSON written by \"save dump --json\"",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			outPath, _ := cmd.PersistentFlags().GetString("output")
+			if outPath == "" {
+				return errors.New("An output file must be given with --output")
+			}
+
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			save := savefile.Save{}
+			dec := json.NewDecoder(f)
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(&save); err != nil {
+				return fmt.Errorf("Failed to read '%s': %w", args[0], err)
+			}
+
+			if err := writeSave(outPath, save, 0644); err != nil {
+				return fmt.Errorf("Failed to write '%s': %w", outPath, err)
+			}
+			return nil
+		},
+	}
+	cmd.PersistentFlags().StringP("output", "o", "", "Path of the save file to write")
+
+	return cmd
+}
@@ -0,0 +1,101 @@
+package savecmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/sector-f/jhmod/savefile"
+	"github.com/spf13/cobra"
+)
+
+// readSave parses the whole save file at path.
+func readSave(path string) (savefile.Save, error) {
+	return parseFile(path, savefile.ParseFull)
+}
+
+// parseFile opens the file at path and parses it with parse.
+func parseFile(path string, parse func(io.Reader) (savefile.Save, error)) (savefile.Save, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return savefile.Save{}, err
+	}
+	defer f.Close()
+
+	return parse(f)
+}
+
+// writeSave writes s to path with the given permissions, by way of a temporary file in the same
+// directory so that an existing save is not left half written if something goes wrong.
+func writeSave(path string, s savefile.Save, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // Fails harmlessly once the file has been renamed
+
+	if err := savefile.Write(tmp, s); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func saveEditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "edit FILE",
+		Short: "Change the player name or seed of a save file",
+		Long: `Change the player name or seed of a save file.
+
+The save is modified in place unless --output is given.  Parts of the save
+that jhmod does not understand are written back unchanged.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := cmd.PersistentFlags()
+			if !flags.Changed("name") && !flags.Changed("seed") {
+				return errors.New("Nothing to change; give --name or --seed")
+			}
+
+			outPath, _ := flags.GetString("output")
+			if outPath == "" {
+				outPath = args[0]
+			}
+
+			info, err := os.Stat(args[0])
+			if err != nil {
+				return err
+			}
+			save, err := readSave(args[0])
+			if err != nil {
+				return err
+			}
+
+			if flags.Changed("name") {
+				save.PlayerName, _ = flags.GetString("name")
+			}
+			if flags.Changed("seed") {
+				save.Seed, _ = flags.GetUint32("seed")
+			}
+
+			if err := writeSave(outPath, save, info.Mode().Perm()); err != nil {
+				return fmt.Errorf("Failed to write '%s': %w", outPath, err)
+			}
+			return nil
+		},
+	}
+	cmd.PersistentFlags().String("name", "", "New player name")
+	cmd.PersistentFlags().Uint32("seed", 0, "New seed")
+	cmd.PersistentFlags().StringP("output", "o", "", "Write the edited save to this path instead of modifying FILE")
+
+	return cmd
+}
@@ -0,0 +1,49 @@
+package savecmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// hexRow formats up to 16 bytes in the style of "hexdump -C", labelled with offset.
+func hexRow(offset int, row []byte) string {
+	hexPart := strings.Builder{}
+	ascii := strings.Builder{}
+	for i := 0; i < 16; i++ {
+		if i == 8 {
+			hexPart.WriteByte(' ')
+		}
+		if i >= len(row) {
+			hexPart.WriteString("   ")
+			continue
+		}
+		fmt.Fprintf(&hexPart, "%02x ", row[i])
+		if row[i] >= 0x20 && row[i] < 0x7f {
+			ascii.WriteByte(row[i])
+		} else {
+			ascii.WriteByte('.')
+		}
+	}
+	return fmt.Sprintf("%08x  %s |%s|", offset, hexPart.String(), ascii.String())
+}
+
+// hexDump writes data to w in the style of "hexdump -C", numbering the lines from start.
+// If mark is within the dumped range, the byte at that offset is pointed out on the following line.
+func hexDump(w io.Writer, start int, data []byte, mark int) {
+	for lineStart := 0; lineStart < len(data); lineStart += 16 {
+		line := data[lineStart:]
+		if len(line) > 16 {
+			line = line[:16]
+		}
+		fmt.Fprintln(w, hexRow(start+lineStart, line))
+
+		if i := mark - start - lineStart; i >= 0 && i < len(line) {
+			column := 10 + 3*i
+			if i >= 8 {
+				column++
+			}
+			fmt.Fprintf(w, "%s^^\n", strings.Repeat(" ", column))
+		}
+	}
+}
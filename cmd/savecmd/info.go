@@ -0,0 +1,242 @@
+package savecmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sector-f/jhmod/savefile"
+	"github.com/spf13/cobra"
+)
+
+// saveInfo is what "save info" reports about a single file.
+type saveInfo struct {
+	File         string
+	Version      uint32
+	KnownVersion bool
+	GameMode     string
+	PlayerName   string
+	CurrentLevel string
+	Seed         uint32
+	Error        string `json:",omitempty"`
+
+	save savefile.Save
+}
+
+// expandInputs turns the arguments of a command into a list of save files.
+// Directories are searched recursively for files whose names match pattern, and arguments
+// that do not name an existing file are treated as glob patterns.
+func expandInputs(args []string, pattern string) ([]string, error) {
+	files := []string{}
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		switch {
+		case err == nil && info.IsDir():
+			walkErr := filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if matched, _ := filepath.Match(pattern, d.Name()); matched && d.Type().IsRegular() {
+					files = append(files, path)
+				}
+				return nil
+			})
+			if walkErr != nil {
+				return nil, walkErr
+			}
+
+		case err != nil && strings.ContainsAny(arg, "*?["):
+			matches, globErr := filepath.Glob(arg)
+			if globErr != nil {
+				return nil, fmt.Errorf("'%s': %w", arg, globErr)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("'%s' does not match any files", arg)
+			}
+			files = append(files, matches...)
+
+		default:
+			// Files that do not exist are reported when they are opened
+			files = append(files, arg)
+		}
+	}
+	return files, nil
+}
+
+// readInfo reads the save file at path. Any error is also recorded in the returned saveInfo.
+// Only the header of the save is read unless full is true.
+func readInfo(path string, full bool) (saveInfo, error) {
+	info := saveInfo{File: path}
+
+	parse := savefile.ParseHeader
+	if full {
+		parse = savefile.ParseFull
+	}
+	save, err := parseFile(path, parse)
+	if err != nil {
+		info.Error = err.Error()
+		return info, err
+	}
+
+	info.Version = save.Version
+	info.KnownVersion = save.KnownVersion()
+	info.GameMode = save.GameMode
+	info.PlayerName = save.PlayerName
+	info.CurrentLevel = save.CurrentLevel
+	info.Seed = save.Seed
+	info.save = save
+	return info, nil
+}
+
+func printText(infos []saveInfo, debug bool) error {
+	for _, info := range infos {
+		if info.Error != "" {
+			continue
+		}
+		save := info.save
+
+		fmt.Printf("Savefile %s\n", info.File)
+		fmt.Printf("  Version:   0x%x\n", save.Version)
+		fmt.Printf("  Game mode: %s\n", save.GameMode)
+		fmt.Printf("  Name:      %s\n", save.PlayerName)
+		fmt.Printf("  Level:     %s\n", save.CurrentLevel)
+		fmt.Printf("  Seed:      %v\n", save.Seed)
+		fmt.Printf("  Unknown:   %v\n", save.HeaderWords())
+		if !save.HeaderOnly {
+			fmt.Printf("  Body:      %d bytes\n", len(save.Body()))
+		}
+		if debug {
+			for _, span := range save.Unknown {
+				fmt.Printf("  Unknown %s at 0x%x (%d bytes)\n", span.Name, span.Offset, len(span.Data))
+			}
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+func printTable(infos []saveInfo) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tVERSION\tMODE\tNAME\tLEVEL\tSEED")
+	for _, info := range infos {
+		if info.Error != "" {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t0x%x\t%s\t%s\t%s\t%d\n", info.File, info.Version, info.GameMode, info.PlayerName, info.CurrentLevel, info.Seed)
+	}
+	return w.Flush()
+}
+
+func printCSV(infos []saveInfo) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Write([]string{"file", "version", "known_version", "game_mode", "player_name", "current_level", "seed", "error"})
+	for _, info := range infos {
+		w.Write([]string{
+			info.File,
+			strconv.FormatUint(uint64(info.Version), 10),
+			strconv.FormatBool(info.KnownVersion),
+			info.GameMode,
+			info.PlayerName,
+			info.CurrentLevel,
+			strconv.FormatUint(uint64(info.Seed), 10),
+			info.Error,
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func printJSON(infos []saveInfo) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(infos)
+}
+
+func saveInfoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "info FILE|DIR ...",
+		Short: "Show information about a save file",
+		Long: `Show information about a save file.
+
+Directories are searched for save files matching --pattern, and arguments may
+be glob patterns such as "saves/*.sav".  The json and csv formats include a
+record for every file, with an error for those that could not be read.  The
+exit status is 1 if any file could not be read.
+
+Only the header of each save is decompressed, unless --debug is given.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			debug, _ := cmd.PersistentFlags().GetBool("debug")
+			format, _ := cmd.PersistentFlags().GetString("format")
+			pattern, _ := cmd.PersistentFlags().GetString("pattern")
+			context, _ := cmd.PersistentFlags().GetInt("context")
+
+			printers := map[string]func([]saveInfo) error{
+				"text":  func(infos []saveInfo) error { return printText(infos, debug) },
+				"table": printTable,
+				"csv":   printCSV,
+				"json":  printJSON,
+			}
+			printInfos, ok := printers[format]
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Unknown format '%s' (expected text, table, csv or json)\n", format)
+				os.Exit(1)
+			}
+
+			files, err := expandInputs(args, pattern)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			infos := []saveInfo{}
+			failed := 0
+			for _, p := range files {
+				if debug {
+					fmt.Fprintf(os.Stderr, "Reading file %s\n", p)
+				}
+
+				info, err := readInfo(p, debug)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to read '%s': %v\n", p, err)
+					failed++
+
+					parseErr := &savefile.ParseError{}
+					if debug && context > 0 && errors.As(err, &parseErr) {
+						start, data := parseErr.Context(context)
+						hexDump(os.Stderr, start, data, parseErr.Offset)
+					}
+				} else if !info.KnownVersion {
+					fmt.Fprintf(os.Stderr, "Warning: '%s' has unknown format version 0x%x, so its fields may be wrong\n", p, info.Version)
+				}
+				for _, warning := range info.save.Warnings {
+					fmt.Fprintf(os.Stderr, "Warning: '%s': %v\n", p, warning)
+				}
+				infos = append(infos, info)
+			}
+
+			if err := printInfos(infos); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+
+			fmt.Fprintf(os.Stderr, "Read %d of %d save files\n", len(files)-failed, len(files))
+			if failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+	cmd.PersistentFlags().BoolP("debug", "d", false, "Show more internal state of the tool.")
+	cmd.PersistentFlags().StringP("format", "f", "text", "Output format: text, table, csv or json")
+	cmd.PersistentFlags().StringP("pattern", "p", "*.sav", "Names of the files to read from directories")
+	cmd.PersistentFlags().IntP("context", "C", 64, "With --debug, bytes to show on either side of where a save could not be parsed")
+
+	return cmd
+}
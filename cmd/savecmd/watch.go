@@ -0,0 +1,103 @@
+package savecmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sector-f/jhmod/savewatch"
+	"github.com/spf13/cobra"
+)
+
+func saveWatchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch DIR",
+		Short: "Copy every save file written to a directory into an archive",
+		Long: `Copy every save file written to a directory into an archive.
+
+Each save is parsed once the game has finished writing it, then copied into
+the archive directory with the time in its name.  The archive's index.jsonl
+has one line of JSON per copy, with the game mode, player name, level and
+seed of the save.  Saves that are unchanged since they were last archived
+are skipped.
+
+On Linux, inotify is used to find out when a save has been written;
+elsewhere, or with --poll, the directory is checked every --interval.
+Press Ctrl-C to stop watching.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := cmd.PersistentFlags()
+			archiveDir, _ := flags.GetString("archive")
+			if archiveDir == "" {
+				return errors.New("An archive directory must be given with --archive")
+			}
+
+			// Copies matching the pattern would be reported as new saves, and archived again forever
+			if inside, err := within(archiveDir, args[0]); err != nil {
+				return err
+			} else if inside {
+				return fmt.Errorf("The archive directory '%s' must not be inside the watched directory '%s'", archiveDir, args[0])
+			}
+
+			archive, err := savewatch.OpenArchive(archiveDir)
+			if err != nil {
+				return fmt.Errorf("Failed to open archive '%s': %w", archiveDir, err)
+			}
+
+			w := savewatch.NewWatcher(args[0])
+			w.Pattern, _ = flags.GetString("pattern")
+			w.Poll, _ = flags.GetBool("poll")
+			w.Interval, _ = flags.GetDuration("interval")
+			w.Settle, _ = flags.GetDuration("settle")
+			w.Warn = func(err error) {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+			defer stop()
+
+			fmt.Fprintf(os.Stderr, "Watching %s, press Ctrl-C to stop\n", args[0])
+			err = w.Run(ctx, func(path string) {
+				r, err := archive.Add(path, time.Now())
+				if errors.Is(err, savewatch.ErrUnchanged) {
+					return
+				} else if err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to archive '%s': %v\n", path, err)
+					return
+				}
+				fmt.Printf("%s\tmode=%s name=%s level=%s seed=%d\n", r.File, r.GameMode, r.PlayerName, r.CurrentLevel, r.Seed)
+			})
+			return err
+		},
+	}
+	cmd.PersistentFlags().StringP("archive", "a", "", "Directory to copy saves into")
+	cmd.PersistentFlags().StringP("pattern", "p", "*.sav", "Only watch files whose names match this pattern")
+	cmd.PersistentFlags().Bool("poll", false, "Poll the directory instead of using change notifications")
+	cmd.PersistentFlags().Duration("interval", time.Second, "How often to poll the directory")
+	cmd.PersistentFlags().Duration("settle", 500*time.Millisecond, "How long a save must be unchanged before it is archived")
+
+	return cmd
+}
+
+// within reports whether path is dir or is inside it.
+func within(path, dir string) (bool, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false, err
+	}
+
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil {
+		return false, nil // On different volumes
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))), nil
+}
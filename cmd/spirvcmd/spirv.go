@@ -0,0 +1,198 @@
+package spirvcmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/sector-f/jhmod/spirv"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	spirvCmd.AddCommand(spirvInfoCmd())
+	spirvCmd.AddCommand(spirvDisCmd())
+}
+
+var spirvCmd = &cobra.Command{
+	Use:   "spirv",
+	Short: "Inspect SPIR-V shader modules",
+}
+
+// parseFile parses the SPIR-V module at path.
+func parseFile(path string) (spirv.Module, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return spirv.Module{}, err
+	}
+	defer f.Close()
+
+	return spirv.Parse(f)
+}
+
+func spirvInfoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "info FILE",
+		Short: "Show the entry points, bindings and decorations of a shader",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			showDecorations, _ := cmd.PersistentFlags().GetBool("decorations")
+
+			m, err := parseFile(args[0])
+			if err != nil {
+				return err
+			}
+			names := m.Names()
+
+			fmt.Printf("Shader %s\n", args[0])
+			fmt.Printf("  Version:      %s\n", m.VersionString())
+			fmt.Printf("  Generator:    0x%08x\n", m.Generator)
+			fmt.Printf("  Bound:        %d\n", m.Bound)
+			fmt.Printf("  Instructions: %d\n", len(m.Instructions))
+			fmt.Println()
+
+			fmt.Println("Entry points:")
+			for _, p := range m.EntryPoints() {
+				fmt.Printf("  %s %q (%%%d)\n", p.Model, p.Name, p.Function)
+				for _, id := range p.Interface {
+					fmt.Printf("    %s\n", idName(id, names))
+				}
+			}
+			fmt.Println()
+
+			printVariables(m.Variables())
+
+			if showDecorations {
+				fmt.Println()
+				fmt.Println("Decorations:")
+				for _, d := range m.Decorations() {
+					target := idName(d.Target, names)
+					if d.Member >= 0 {
+						target = fmt.Sprintf("%s member %d", target, d.Member)
+					}
+
+					fmt.Printf("  %s: %s", target, d.Kind)
+					for _, v := range d.Operands {
+						if d.Kind == spirv.DecorationBuiltIn {
+							fmt.Printf(" %s", spirv.BuiltIn(v))
+						} else {
+							fmt.Printf(" %d", v)
+						}
+					}
+					fmt.Println()
+				}
+			}
+
+			return nil
+		},
+	}
+	cmd.PersistentFlags().BoolP("decorations", "d", false, "List every decoration in the module")
+
+	return cmd
+}
+
+// idName returns "%N" followed by the debug name of id, if it has one.
+func idName(id uint32, names map[uint32]string) string {
+	if name, exists := names[id]; exists {
+		return fmt.Sprintf("%%%d %s", id, name)
+	}
+	return fmt.Sprintf("%%%d", id)
+}
+
+// varName returns "%N" followed by the name of v, if it has one.
+func varName(v spirv.Variable) string {
+	if v.Name == "" {
+		return fmt.Sprintf("%%%d", v.ID)
+	}
+	return fmt.Sprintf("%%%d %s", v.ID, v.Name)
+}
+
+// printVariables prints the descriptor bindings, push constants, inputs and outputs among vars.
+func printVariables(vars []spirv.Variable) {
+	bindings := []spirv.Variable{}
+	pushConstants := []spirv.Variable{}
+	inputs := []spirv.Variable{}
+	outputs := []spirv.Variable{}
+
+	for _, v := range vars {
+		if _, hasBinding := v.Decoration(spirv.DecorationBinding); hasBinding {
+			bindings = append(bindings, v)
+			continue
+		}
+
+		switch v.StorageClass {
+		case spirv.StorageClassPushConstant:
+			pushConstants = append(pushConstants, v)
+		case spirv.StorageClassInput:
+			inputs = append(inputs, v)
+		case spirv.StorageClassOutput:
+			outputs = append(outputs, v)
+		}
+	}
+
+	sort.SliceStable(bindings, func(i, j int) bool {
+		si, _ := bindings[i].Decoration(spirv.DecorationDescriptorSet)
+		sj, _ := bindings[j].Decoration(spirv.DecorationDescriptorSet)
+		bi, _ := bindings[i].Decoration(spirv.DecorationBinding)
+		bj, _ := bindings[j].Decoration(spirv.DecorationBinding)
+		return si < sj || (si == sj && bi < bj)
+	})
+
+	fmt.Println("Descriptor bindings:")
+	for _, v := range bindings {
+		set, _ := v.Decoration(spirv.DecorationDescriptorSet)
+		binding, _ := v.Decoration(spirv.DecorationBinding)
+		fmt.Printf("  set %d binding %d: %s (%s)\n", set, binding, varName(v), v.StorageClass)
+	}
+
+	if len(pushConstants) > 0 {
+		fmt.Println()
+		fmt.Println("Push constants:")
+		for _, v := range pushConstants {
+			fmt.Printf("  %s\n", varName(v))
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("Inputs:")
+	printInterface(inputs)
+
+	fmt.Println()
+	fmt.Println("Outputs:")
+	printInterface(outputs)
+}
+
+// printInterface prints shader inputs or outputs with their locations or built-in names.
+func printInterface(vars []spirv.Variable) {
+	for _, v := range vars {
+		if b, isBuiltIn := v.Decoration(spirv.DecorationBuiltIn); isBuiltIn {
+			fmt.Printf("  built-in %s: %s\n", spirv.BuiltIn(b), varName(v))
+		} else if loc, hasLocation := v.Decoration(spirv.DecorationLocation); hasLocation {
+			fmt.Printf("  location %d: %s\n", loc, varName(v))
+		} else {
+			fmt.Printf("  %s\n", varName(v))
+		}
+	}
+}
+
+func spirvDisCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dis FILE",
+		Short: "Disassemble a shader",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := parseFile(args[0])
+			if err != nil {
+				return err
+			}
+
+			return spirv.Disassemble(os.Stdout, m)
+		},
+	}
+
+	return cmd
+}
+
+func Cmd() *cobra.Command {
+	return spirvCmd
+}
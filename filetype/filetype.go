@@ -0,0 +1,254 @@
+// Package filetype identifies the type of a file from its contents.
+//
+// Archive members are only identified by the hash of their path, so when a member's path is unknown
+// its contents are the only way to decide how it should be named when it is extracted.
+package filetype
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"unicode/utf8"
+)
+
+// sniffLen is the number of bytes that content heuristics look at.
+const sniffLen = 4096
+
+// Type describes a kind of file.
+type Type struct {
+	Name string // Short name of the type, such as "png" or "lua"
+	Dir  string // Name of the directory that unknown files of this type are extracted to
+	Ext  string // File extension, including the leading dot
+}
+
+// Unknown is returned by Detect when no detector matches.
+var Unknown = Type{"unknown", "unknown", ".unknown"}
+
+// newType returns the Type for name, using the naming scheme shared by all built-in types.
+func newType(name string, ext string) Type {
+	return Type{name, "unknown_" + name, ext}
+}
+
+// Detector recognises a single type of file.
+//
+// If Magic is set, the data must contain Magic at Offset.
+// If Match is set, it must also return true for the data.
+// A Detector with neither set matches everything.
+type Detector struct {
+	Type   Type
+	Magic  []byte
+	Offset int
+	Match  func(data []byte) bool
+}
+
+// matches reports whether d recognises data.
+func (d Detector) matches(data []byte) bool {
+	if d.Magic != nil {
+		end := d.Offset + len(d.Magic)
+		if end > len(data) || !bytes.Equal(data[d.Offset:end], d.Magic) {
+			return false
+		}
+	}
+	if d.Match != nil && !d.Match(data) {
+		return false
+	}
+	return true
+}
+
+// Registry is an ordered list of Detectors.
+// More specific detectors should be registered before more general ones, since the first match wins.
+type Registry struct {
+	detectors []Detector
+}
+
+// Register adds d to the end of r.
+func (r *Registry) Register(d Detector) {
+	r.detectors = append(r.detectors, d)
+}
+
+// Detect returns the Type of the first detector in r that recognises data, or Unknown.
+func (r *Registry) Detect(data []byte) Type {
+	for _, d := range r.detectors {
+		if d.matches(data) {
+			return d.Type
+		}
+	}
+	return Unknown
+}
+
+// Default holds the built-in detectors.
+var Default = defaultRegistry()
+
+// Detect returns the Type of data according to the Default registry.
+func Detect(data []byte) Type {
+	return Default.Detect(data)
+}
+
+// Built-in types.
+var (
+	PNG      = newType("png", ".png")
+	NMD      = newType("nmd", ".nmd")
+	Ogg      = newType("ogg", ".ogg")
+	WAV      = newType("wav", ".wav")
+	SPIRV    = newType("spirv", ".spirv")
+	DDS      = newType("dds", ".dds")
+	KTX      = newType("ktx", ".ktx")
+	KTX2     = newType("ktx2", ".ktx2")
+	TrueType = newType("ttf", ".ttf")
+	OpenType = newType("otf", ".otf")
+	LuaByte  = newType("luac", ".luac")
+	LuaJIT   = newType("luajit", ".luac")
+	JSON     = newType("json", ".json")
+	GLSL     = newType("glsl", ".glsl")
+	Lua      = newType("lua", ".lua")
+	CSV      = newType("csv", ".csv")
+	Text     = newType("txt", ".txt")
+)
+
+func defaultRegistry() *Registry {
+	r := &Registry{}
+
+	r.Register(Detector{Type: PNG, Magic: []byte("\x89PNG")})
+	r.Register(Detector{Type: NMD, Magic: []byte("nmf1")})
+	r.Register(Detector{Type: Ogg, Magic: []byte("OggS")})
+	r.Register(Detector{Type: WAV, Magic: []byte("RIFF")})
+	r.Register(Detector{Type: SPIRV, Magic: []byte("\x03\x02\x23\x07")})
+	r.Register(Detector{Type: DDS, Magic: []byte("DDS ")})
+	r.Register(Detector{Type: KTX, Magic: []byte("\xabKTX 11\xbb\r\n\x1a\n")})
+	r.Register(Detector{Type: KTX2, Magic: []byte("\xabKTX 20\xbb\r\n\x1a\n")})
+	r.Register(Detector{Type: TrueType, Magic: []byte("\x00\x01\x00\x00"), Match: isFont})
+	r.Register(Detector{Type: TrueType, Magic: []byte("true"), Match: isFont})
+	r.Register(Detector{Type: OpenType, Magic: []byte("OTTO"), Match: isFont})
+	r.Register(Detector{Type: LuaByte, Magic: []byte("\x1bLua")})
+	r.Register(Detector{Type: LuaJIT, Magic: []byte("\x1bLJ")})
+
+	// Text formats are only recognised by their content, so they come last
+	r.Register(Detector{Type: JSON, Match: isJSON})
+	r.Register(Detector{Type: GLSL, Match: isGLSL})
+	r.Register(Detector{Type: Lua, Match: isLua})
+	r.Register(Detector{Type: CSV, Match: isCSV})
+	r.Register(Detector{Type: Text, Match: isText})
+
+	return r
+}
+
+// isFont checks that a font's table directory header is plausible, since its magic bytes are short and common.
+func isFont(data []byte) bool {
+	if len(data) < 12 {
+		return false
+	}
+	numTables := int(data[4])<<8 | int(data[5])
+	return numTables > 0 && numTables < 256 && len(data) >= 12+16*numTables
+}
+
+// sniff returns the start of data, cut at a rune boundary.
+// The second return value is true if data was cut short.
+func sniff(data []byte) ([]byte, bool) {
+	if len(data) <= sniffLen {
+		return data, false
+	}
+
+	end := sniffLen
+	for end > sniffLen-utf8.UTFMax && !utf8.RuneStart(data[end]) {
+		end--
+	}
+	return data[:end], true
+}
+
+// isText reports whether the start of data looks like UTF-8 text.
+func isText(data []byte) bool {
+	sample, _ := sniff(data)
+	if len(sample) == 0 || !utf8.Valid(sample) {
+		return false
+	}
+
+	for _, b := range sample {
+		if b < 0x20 && b != '\n' && b != '\r' && b != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// isJSON reports whether data is a JSON object or array.
+func isJSON(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return false
+	}
+	return json.Valid(trimmed)
+}
+
+// isGLSL reports whether data looks like GLSL shader source.
+func isGLSL(data []byte) bool {
+	if !isText(data) {
+		return false
+	}
+	sample, _ := sniff(data)
+
+	if bytes.HasPrefix(bytes.TrimSpace(sample), []byte("#version")) {
+		return true
+	}
+	return bytes.Contains(sample, []byte("void main(")) &&
+		(bytes.Contains(sample, []byte("gl_")) || bytes.Contains(sample, []byte("layout(")) || bytes.Contains(sample, []byte("uniform ")))
+}
+
+// luaHints are line prefixes that are common in Lua source and rare in other text formats.
+var luaHints = [][]byte{
+	[]byte("--"),
+	[]byte("local "),
+	[]byte("function "),
+	[]byte("return "),
+	[]byte("require"),
+	[]byte("end"),
+}
+
+// isLua reports whether data looks like Lua source code.
+func isLua(data []byte) bool {
+	if !isText(data) {
+		return false
+	}
+	sample, _ := sniff(data)
+
+	lines, hints := 0, 0
+	for _, line := range bytes.Split(sample, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		lines++
+
+		for _, hint := range luaHints {
+			if bytes.HasPrefix(line, hint) {
+				hints++
+				break
+			}
+		}
+	}
+
+	// A single line is enough for tiny files such as stubs that only return a table
+	return hints > 0 && (lines < 4 || hints*10 >= lines)
+}
+
+// isCSV reports whether data looks like comma separated values with a consistent number of fields.
+func isCSV(data []byte) bool {
+	if !isText(data) {
+		return false
+	}
+	sample, truncated := sniff(data)
+	if truncated {
+		// Drop the last line, which is probably incomplete
+		if i := bytes.LastIndexByte(sample, '\n'); i >= 0 {
+			sample = sample[:i+1]
+		}
+	}
+
+	r := csv.NewReader(bytes.NewReader(sample))
+	r.LazyQuotes = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return false
+	}
+
+	return len(records) >= 2 && len(records[0]) >= 2
+}
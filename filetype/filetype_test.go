@@ -0,0 +1,80 @@
+package filetype
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	font := append([]byte("\x00\x01\x00\x00\x00\x01"), make([]byte, 6+16)...)
+
+	tests := []struct {
+		name     string
+		data     string
+		expected Type
+	}{
+		{"empty", "", Unknown},
+		{"short", "\x89P", Unknown},
+		{"binary", "\x00\x01\x02\x03\x04\x05", Unknown},
+		{"png", "\x89PNG\r\n\x1a\n\x00\x00", PNG},
+		{"nmd", "nmf1\x00\x00\x00\x00", NMD},
+		{"ogg", "OggS\x00\x02", Ogg},
+		{"wav", "RIFF\x24\x00\x00\x00WAVE", WAV},
+		{"spirv", "\x03\x02\x23\x07\x00\x00\x01\x00", SPIRV},
+		{"dds", "DDS \x7c\x00\x00\x00", DDS},
+		{"ktx", "\xabKTX 11\xbb\r\n\x1a\n\x01\x02\x03\x04", KTX},
+		{"ktx2", "\xabKTX 20\xbb\r\n\x1a\n", KTX2},
+		{"ttf", string(font), TrueType},
+		{"ttf magic without tables", "\x00\x01\x00\x00\x00\x00", Unknown},
+		{"lua 5.1 bytecode", "\x1bLuaQ\x00\x01\x04", LuaByte},
+		{"lua 5.4 bytecode", "\x1bLuaT\x00\x19\x93\r\n\x1a\n", LuaByte},
+		{"luajit bytecode", "\x1bLJ\x02\x00", LuaJIT},
+		{"json object", "{\"a\": [1, 2, 3]}\n", JSON},
+		{"json array", "  [\"x\", \"y\"]", JSON},
+		{"invalid json", "{\"a\": }", Text},
+		{"glsl version", "#version 450\nlayout(location = 0) in vec3 pos;\n", GLSL},
+		{"glsl main", "uniform mat4 mvp;\nvoid main() {\n\tgl_Position = mvp * pos;\n}\n", GLSL},
+		{"lua module", "-- A module\nlocal M = {}\n\nfunction M.foo()\n\treturn 1\nend\n\nreturn M\n", Lua},
+		{"lua stub", "return {}\n", Lua},
+		{"csv", "id,text\nhello,Hallo\nbye,Tschüss\n", CSV},
+		{"csv with quotes", "id,text\n\"a\",\"one, two\"\n\"b\",\"three\"\n", CSV},
+		{"text", "Just some notes.\nNothing in particular.\n", Text},
+		{"invalid utf-8", "caf\xe9\n", Unknown},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Detect([]byte(test.data))
+			if got != test.expected {
+				t.Fatalf("Got %v, expected %v", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestDetectLongText(t *testing.T) {
+	// Longer than sniffLen, with a multi-byte rune straddling the cut
+	line := []byte("key,Übersetzung\n")
+	data := bytes.Repeat(line, 2*sniffLen/len(line))
+
+	if got := Detect(data); got != CSV {
+		t.Fatalf("Got %v, expected %v", got, CSV)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	custom := Type{"custom", "custom", ".bin"}
+
+	r := &Registry{}
+	r.Register(Detector{Type: custom, Magic: []byte("ID"), Offset: 2})
+
+	if got := r.Detect([]byte("xxID")); got != custom {
+		t.Fatalf("Got %v, expected %v", got, custom)
+	}
+	if got := r.Detect([]byte("IDxx")); got != Unknown {
+		t.Fatalf("Got %v, expected %v", got, Unknown)
+	}
+	if got := r.Detect([]byte("xxI")); got != Unknown {
+		t.Fatalf("Got %v, expected %v", got, Unknown)
+	}
+}
@@ -0,0 +1,246 @@
+// Package gamedir finds the Jupiter Hell game directory, which holds the game's .nvc archives.
+//
+// The directory is looked for in this order: the JHMOD_GAME_DIR environment variable, jhmod's
+// config file, the Steam libraries listed in Steam's libraryfolders.vdf, then a few common install
+// locations. A directory is only accepted if it has both core.nvc and assets.nvc in it.
+package gamedir
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const (
+	// EnvVar is the environment variable that names the game directory, overriding everything else.
+	EnvVar = "JHMOD_GAME_DIR"
+	// Core is the archive that holds the game's code and data.
+	Core = "core.nvc"
+	// Assets is the archive that holds the game's graphics and sounds.
+	Assets = "assets.nvc"
+	// steamName is the name of the game's folder in a Steam library.
+	steamName = "Jupiter Hell"
+)
+
+// ErrNotFound is returned by Find when no game directory is found.
+var ErrNotFound = errors.New("Jupiter Hell game directory not found; set " + EnvVar + " or run \"jhmod gamedir set DIR\"")
+
+// CorePath returns the path of core.nvc in the game directory dir.
+func CorePath(dir string) string {
+	return filepath.Join(dir, Core)
+}
+
+// AssetsPath returns the path of assets.nvc in the game directory dir.
+func AssetsPath(dir string) string {
+	return filepath.Join(dir, Assets)
+}
+
+// Validate returns an error unless dir is a directory holding both of the game's archives.
+func Validate(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	} else if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	for _, name := range []string{Core, Assets} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("%s is not a Jupiter Hell game directory: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// Config is the contents of jhmod's config file.
+type Config struct {
+	GameDir string `json:",omitempty"`
+}
+
+// ConfigPath returns the path of jhmod's config file.
+func ConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "jhmod", "config.json"), nil
+}
+
+// ReadConfig reads the config file at path. If there is no file at path, an empty Config is returned.
+func ReadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Config{}, nil
+	} else if err != nil {
+		return Config{}, err
+	}
+
+	c := Config{}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// WriteConfig writes c to the config file at path, creating its directory if needed.
+func WriteConfig(path string, c Config) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Candidate is a directory that might be the game directory.
+type Candidate struct {
+	Dir string
+	// From says where the directory came from, such as "JHMOD_GAME_DIR" or a libraryfolders.vdf file.
+	From string
+	// Explicit is true for directories chosen by the user, which are not skipped over if they are invalid.
+	Explicit bool
+}
+
+// Finder looks for the game directory. Its fields say where to look.
+type Finder struct {
+	// Getenv looks up environment variables.
+	Getenv func(string) string
+	// ConfigFile is the path of jhmod's config file, or empty to not read one.
+	ConfigFile string
+	// SteamRoots are the directories where Steam might be installed.
+	SteamRoots []string
+	// Paths are other directories where the game might be installed.
+	Paths []string
+}
+
+// DefaultFinder returns a Finder that looks in the usual places for the current system.
+func DefaultFinder() *Finder {
+	f := &Finder{Getenv: os.Getenv}
+	if path, err := ConfigPath(); err == nil {
+		f.ConfigFile = path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return f
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"ProgramFiles(x86)", "ProgramFiles"} {
+			if dir := os.Getenv(env); dir != "" {
+				f.SteamRoots = append(f.SteamRoots, filepath.Join(dir, "Steam"))
+			}
+		}
+		f.Paths = []string{`C:\GOG Games\Jupiter Hell`}
+	case "darwin":
+		f.SteamRoots = []string{filepath.Join(home, "Library", "Application Support", "Steam")}
+	default:
+		f.SteamRoots = []string{
+			filepath.Join(home, ".steam", "steam"),
+			filepath.Join(home, ".local", "share", "Steam"),
+			filepath.Join(home, ".var", "app", "com.valvesoftware.Steam", ".local", "share", "Steam"),
+		}
+		f.Paths = []string{
+			filepath.Join(home, "GOG Games", "Jupiter Hell"),
+			filepath.Join(home, "Games", "Jupiter Hell"),
+			filepath.Join(home, "Games", "jupiter-hell"),
+		}
+	}
+	return f
+}
+
+// Candidates returns the directories that f would try, in the order it would try them.
+// Errors reading the config file or Steam's library lists are returned alongside the candidates that could be found.
+func (f *Finder) Candidates() ([]Candidate, []error) {
+	candidates, errs, configErr := f.candidates()
+	if configErr != nil {
+		errs = append([]error{configErr}, errs...)
+	}
+	return candidates, errs
+}
+
+// candidates is like Candidates, but returns the error reading the config file separately from the others.
+func (f *Finder) candidates() ([]Candidate, []error, error) {
+	candidates := []Candidate{}
+	errs := []error{}
+	var configErr error
+
+	if f.Getenv != nil {
+		if dir := f.Getenv(EnvVar); dir != "" {
+			candidates = append(candidates, Candidate{dir, EnvVar, true})
+		}
+	}
+
+	if f.ConfigFile != "" {
+		c, err := ReadConfig(f.ConfigFile)
+		if err != nil {
+			configErr = err
+		} else if c.GameDir != "" {
+			candidates = append(candidates, Candidate{c.GameDir, f.ConfigFile, true})
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, root := range f.SteamRoots {
+		libraries := []string{root}
+		vdf := filepath.Join(root, "steamapps", "libraryfolders.vdf")
+		if file, err := os.Open(vdf); err == nil {
+			paths, err := ParseLibraryFolders(file)
+			file.Close()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", vdf, err))
+			}
+			libraries = append(libraries, paths...)
+		}
+
+		for _, library := range libraries {
+			dir := filepath.Join(library, "steamapps", "common", steamName)
+			if !seen[dir] {
+				seen[dir] = true
+				candidates = append(candidates, Candidate{dir, "Steam library " + library, false})
+			}
+		}
+	}
+
+	for _, dir := range f.Paths {
+		candidates = append(candidates, Candidate{dir, "common install location", false})
+	}
+	return candidates, errs, configErr
+}
+
+// Find returns the first valid game directory among f's candidates.
+// If the user chose a directory that is not valid, or the config file cannot be read, an error saying so
+// is returned rather than looking further.
+func (f *Finder) Find() (Candidate, error) {
+	candidates, _, configErr := f.candidates()
+	for _, c := range candidates {
+		// The config file comes after the environment variable, so only a directory from that can be used
+		if configErr != nil && !c.Explicit {
+			return Candidate{}, fmt.Errorf("reading config file: %w", configErr)
+		}
+
+		err := Validate(c.Dir)
+		if err == nil {
+			return c, nil
+		}
+		if c.Explicit {
+			return Candidate{}, fmt.Errorf("game directory from %s: %w", c.From, err)
+		}
+	}
+	if configErr != nil {
+		return Candidate{}, fmt.Errorf("reading config file: %w", configErr)
+	}
+	return Candidate{}, ErrNotFound
+}
+
+// Find returns the game directory found by DefaultFinder.
+func Find() (string, error) {
+	c, err := DefaultFinder().Find()
+	return c.Dir, err
+}
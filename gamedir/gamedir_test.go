@@ -0,0 +1,133 @@
+package gamedir
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// makeGameDir creates a fake game directory holding the given archives.
+func makeGameDir(t *testing.T, dir string, archives ...string) string {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range archives {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("NVC"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func env(vars map[string]string) func(string) string {
+	return func(key string) string {
+		return vars[key]
+	}
+}
+
+func TestValidate(t *testing.T) {
+	root := t.TempDir()
+	if err := Validate(makeGameDir(t, filepath.Join(root, "ok"), Core, Assets)); err != nil {
+		t.Fatalf("Got %v, expected no error", err)
+	}
+	if err := Validate(makeGameDir(t, filepath.Join(root, "partial"), Core)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Got %v, expected %v", err, os.ErrNotExist)
+	}
+	if err := Validate(filepath.Join(root, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Got %v, expected %v", err, os.ErrNotExist)
+	}
+}
+
+func TestFindSteam(t *testing.T) {
+	root := t.TempDir()
+	steam := filepath.Join(root, "Steam")
+	library := filepath.Join(root, "library")
+	game := makeGameDir(t, filepath.Join(library, "steamapps", "common", "Jupiter Hell"), Core, Assets)
+
+	vdf := `"libraryfolders" { "0" { "path" "` + steam + `" } "1" { "path" "` + library + `" } }`
+	if err := os.MkdirAll(filepath.Join(steam, "steamapps"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(steam, "steamapps", "libraryfolders.vdf"), []byte(vdf), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &Finder{Getenv: env(nil), SteamRoots: []string{steam}}
+	found, err := f.Find()
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if found.Dir != game || !strings.Contains(found.From, library) {
+		t.Fatalf("Got %+v, expected %s from the Steam library", found, game)
+	}
+
+	candidates, errs := f.Candidates()
+	if len(candidates) != 2 || len(errs) != 0 {
+		t.Fatalf("Got %+v and %v, expected two candidates and no errors", candidates, errs)
+	}
+}
+
+func TestFindOrder(t *testing.T) {
+	root := t.TempDir()
+	envDir := makeGameDir(t, filepath.Join(root, "env"), Core, Assets)
+	configDir := makeGameDir(t, filepath.Join(root, "config"), Core, Assets)
+	commonDir := makeGameDir(t, filepath.Join(root, "common"), Core, Assets)
+	brokenDir := makeGameDir(t, filepath.Join(root, "broken"), Core)
+
+	configFile := filepath.Join(root, "jhmod", "config.json")
+	if err := WriteConfig(configFile, Config{GameDir: configDir}); err != nil {
+		t.Fatalf("WriteConfig failed: %v", err)
+	}
+
+	badConfig := filepath.Join(root, "bad.json")
+	if err := os.WriteFile(badConfig, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		finder   *Finder
+		expected string
+		err      bool
+	}{
+		{&Finder{Getenv: env(map[string]string{EnvVar: envDir}), ConfigFile: configFile, Paths: []string{commonDir}}, envDir, false},
+		{&Finder{Getenv: env(nil), ConfigFile: configFile, Paths: []string{commonDir}}, configDir, false},
+		{&Finder{Getenv: env(nil), Paths: []string{brokenDir, commonDir}}, commonDir, false},
+		{&Finder{Getenv: env(map[string]string{EnvVar: brokenDir}), Paths: []string{commonDir}}, "", true},
+		{&Finder{Getenv: env(nil), Paths: []string{brokenDir}}, "", true},
+		{&Finder{Getenv: env(nil), ConfigFile: badConfig, Paths: []string{commonDir}}, "", true},
+		{&Finder{Getenv: env(map[string]string{EnvVar: envDir}), ConfigFile: badConfig, Paths: []string{commonDir}}, envDir, false},
+	}
+	for i, test := range tests {
+		found, err := test.finder.Find()
+		if (err != nil) != test.err || found.Dir != test.expected {
+			t.Fatalf("Test %d: Got %q, %v, expected %q", i, found.Dir, err, test.expected)
+		}
+	}
+
+	if _, err := (&Finder{Getenv: env(nil)}).Find(); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Got %v, expected %v", err, ErrNotFound)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if c, err := ReadConfig(path); err != nil || c.GameDir != "" {
+		t.Fatalf("Got %+v, %v, expected an empty config", c, err)
+	}
+
+	if err := WriteConfig(path, Config{GameDir: "/games/jh"}); err != nil {
+		t.Fatalf("WriteConfig failed: %v", err)
+	}
+	if c, err := ReadConfig(path); err != nil || c.GameDir != "/games/jh" {
+		t.Fatalf("Got %+v, %v, expected /games/jh", c, err)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadConfig(path); err == nil {
+		t.Fatalf("Got no error for an invalid config file")
+	}
+}
@@ -0,0 +1,194 @@
+package gamedir
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// vdfNode is a value in a Valve KeyValues (.vdf) file: either a string or a list of child keys.
+type vdfNode struct {
+	value    string
+	children []vdfPair
+}
+
+type vdfPair struct {
+	key  string
+	node *vdfNode
+}
+
+// child returns the first child of n called key, ignoring case as Steam does.
+func (n *vdfNode) child(key string) *vdfNode {
+	for _, c := range n.children {
+		if strings.EqualFold(c.key, key) {
+			return c.node
+		}
+	}
+	return nil
+}
+
+type vdfParser struct {
+	r    *bufio.Reader
+	line int
+}
+
+// token returns the next string or brace in the file, and whether it was quoted.
+func (p *vdfParser) token() (string, bool, error) {
+	for {
+		b, err := p.r.ReadByte()
+		if err != nil {
+			return "", false, err
+		}
+
+		switch {
+		case b == '\n':
+			p.line++
+		case b == ' ' || b == '\t' || b == '\r':
+		case b == '/':
+			// Comments start with //
+			if next, _ := p.r.Peek(1); len(next) == 1 && next[0] == '/' {
+				if _, err := p.r.ReadString('\n'); err != nil && !errors.Is(err, io.EOF) {
+					return "", false, err
+				}
+				p.line++
+			} else {
+				return "", false, fmt.Errorf("line %d: unexpected '/'", p.line)
+			}
+		case b == '{' || b == '}':
+			return string(b), false, nil
+		case b == '"':
+			s := strings.Builder{}
+			for {
+				b, err := p.r.ReadByte()
+				if err != nil {
+					return "", false, fmt.Errorf("line %d: unterminated string", p.line)
+				}
+				if b == '"' {
+					return s.String(), true, nil
+				}
+				if b == '\n' {
+					p.line++
+				}
+				if b == '\\' {
+					if b, err = p.r.ReadByte(); err != nil {
+						return "", false, fmt.Errorf("line %d: unterminated string", p.line)
+					}
+					if b == 'n' {
+						b = '\n'
+					} else if b == 't' {
+						b = '\t'
+					}
+				}
+				s.WriteByte(b)
+			}
+		default:
+			// Unquoted strings run to the next space
+			s := strings.Builder{}
+			s.WriteByte(b)
+			for {
+				next, err := p.r.Peek(1)
+				if err != nil || strings.ContainsRune(" \t\r\n{}\"", rune(next[0])) {
+					return s.String(), true, nil
+				}
+				p.r.ReadByte()
+				s.WriteByte(next[0])
+			}
+		}
+	}
+}
+
+// children parses key and value pairs until a closing brace, or the end of the file if top is true.
+func (p *vdfParser) children(top bool) ([]vdfPair, error) {
+	pairs := []vdfPair{}
+	for {
+		key, isString, err := p.token()
+		if errors.Is(err, io.EOF) && top {
+			return pairs, nil
+		} else if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("line %d: missing '}'", p.line)
+			}
+			return nil, err
+		}
+		if key == "}" && !isString {
+			if top {
+				return nil, fmt.Errorf("line %d: unexpected '}'", p.line)
+			}
+			return pairs, nil
+		}
+		if !isString {
+			return nil, fmt.Errorf("line %d: expected a key, got '%s'", p.line, key)
+		}
+
+		value, isString, err := p.token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("line %d: missing value for %q", p.line, key)
+			}
+			return nil, err
+		}
+		node := &vdfNode{value: value}
+		if !isString {
+			if value != "{" {
+				return nil, fmt.Errorf("line %d: unexpected '%s'", p.line, value)
+			}
+			node.value = ""
+			if node.children, err = p.children(false); err != nil {
+				return nil, err
+			}
+		}
+		pairs = append(pairs, vdfPair{key, node})
+	}
+}
+
+// ParseLibraryFolders returns the paths of the Steam libraries listed in a libraryfolders.vdf file.
+//
+// Both the current format, where each numbered library has a "path" key, and the older one, where
+// the numbered keys are the paths themselves, are understood. Libraries are returned in the order
+// of their numbers.
+func ParseLibraryFolders(r io.Reader) ([]string, error) {
+	p := &vdfParser{r: bufio.NewReader(r), line: 1}
+	pairs, err := p.children(true)
+	if err != nil {
+		return nil, err
+	}
+	root := (&vdfNode{children: pairs}).child("libraryfolders")
+	if root == nil {
+		return nil, errors.New("no libraryfolders section")
+	}
+
+	type library struct {
+		index int
+		path  string
+	}
+	libraries := []library{}
+	for _, c := range root.children {
+		index, err := strconv.Atoi(c.key)
+		if err != nil {
+			continue // Not a library, such as "contentstatsid"
+		}
+
+		path := c.node.value
+		if c.node.children != nil {
+			if p := c.node.child("path"); p != nil {
+				path = p.value
+			}
+		}
+		if path != "" {
+			libraries = append(libraries, library{index, path})
+		}
+	}
+
+	sort.SliceStable(libraries, func(i, j int) bool {
+		return libraries[i].index < libraries[j].index
+	})
+	paths := make([]string, len(libraries))
+	for i, l := range libraries {
+		paths[i] = l.path
+	}
+	return paths, nil
+}
@@ -0,0 +1,73 @@
+package gamedir
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLibraryFolders(t *testing.T) {
+	tests := []struct {
+		name     string
+		vdf      string
+		expected []string
+	}{
+		{
+			"current",
+			`"libraryfolders"
+{
+	"1"
+	{
+		"path"		"/mnt/games/SteamLibrary"
+		"label"		""
+		"apps"
+		{
+			"811320"		"4286362112"
+		}
+	}
+	"0"
+	{
+		"path"		"/home/user/.local/share/Steam"
+	}
+}
+`,
+			[]string{"/home/user/.local/share/Steam", "/mnt/games/SteamLibrary"},
+		},
+		{
+			"old",
+			`// Comment
+"LibraryFolders"
+{
+	"TimeNextStatsReport"	"1600000000"
+	"ContentStatsID"		"-1234"
+	"1"		"D:\\SteamLibrary"
+}`,
+			[]string{`D:\SteamLibrary`},
+		},
+		{"empty", `"libraryfolders" { }`, []string{}},
+	}
+
+	for _, test := range tests {
+		paths, err := ParseLibraryFolders(strings.NewReader(test.vdf))
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if strings.Join(paths, "|") != strings.Join(test.expected, "|") {
+			t.Fatalf("%s: Got %q, expected %q", test.name, paths, test.expected)
+		}
+	}
+}
+
+func TestParseLibraryFoldersErrors(t *testing.T) {
+	invalid := []string{
+		`"libraryfolders" {`,
+		`"libraryfolders" { "0" { "path" "/a" }`,
+		`"libraryfolders" { "0" "unterminated }`,
+		`"other" { }`,
+		`}`,
+	}
+	for _, vdf := range invalid {
+		if _, err := ParseLibraryFolders(strings.NewReader(vdf)); err == nil {
+			t.Fatalf("Got no error for %q", vdf)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Package lang reads and writes the game's localization tables.
+//
+// Each language is stored in the archive as a CSV file at data/lang/<language>.csv.
+// The first column of each record is the key that the game looks strings up by, and the second is
+// the translated text. Any further columns are kept as they are, so that a table can be written
+// back without losing anything.
+package lang
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"path"
+	"strings"
+)
+
+// Dir is the directory within an archive that holds the localization tables.
+const Dir = "data/lang"
+
+// utf8BOM is written by some spreadsheet programs at the start of CSV files.
+const utf8BOM = "\xef\xbb\xbf"
+
+// Path returns the path of the table for language within an archive.
+func Path(language string) string {
+	return path.Join(Dir, language+".csv")
+}
+
+// Entry is a single localized string.
+type Entry struct {
+	Key   string
+	Text  string
+	Extra []string // Columns after the text, if any
+}
+
+// Translated reports whether e has been translated, that is whether its text is neither empty nor the same as its key.
+func (e Entry) Translated() bool {
+	return e.Text != "" && e.Text != e.Key
+}
+
+// Table is the contents of a localization CSV file.
+type Table struct {
+	Entries []Entry
+
+	bom   bool // File started with a UTF-8 byte order mark
+	crlf  bool // Lines ended with CRLF
+	index map[string]int
+}
+
+// Parse reads a table from r.
+func Parse(r io.Reader) (*Table, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Table{index: map[string]int{}}
+	if bytes.HasPrefix(data, []byte(utf8BOM)) {
+		t.bom = true
+		data = data[len(utf8BOM):]
+	}
+	if i := bytes.IndexByte(data, '\n'); i > 0 && data[i-1] == '\r' {
+		t.crlf = true
+	}
+
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		e := Entry{Key: record[0]}
+		if len(record) > 1 {
+			e.Text = record[1]
+		}
+		if len(record) > 2 {
+			e.Extra = record[2:]
+		}
+		t.add(e)
+	}
+
+	return t, nil
+}
+
+// add appends e to t. Only the first entry for each key can be looked up.
+func (t *Table) add(e Entry) {
+	if _, exists := t.index[e.Key]; !exists {
+		t.index[e.Key] = len(t.Entries)
+	}
+	t.Entries = append(t.Entries, e)
+}
+
+// Lookup returns the entry for key.
+func (t *Table) Lookup(key string) (Entry, bool) {
+	i, ok := t.index[key]
+	if !ok {
+		return Entry{}, false
+	}
+	return t.Entries[i], true
+}
+
+// Write writes t to w as CSV, keeping the byte order mark and line endings of the file it was parsed from.
+func (t *Table) Write(w io.Writer) error {
+	if t.bom {
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return err
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = t.crlf
+	for _, e := range t.Entries {
+		record := append([]string{e.Key, e.Text}, e.Extra...)
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Bytes returns t as it would be written by Write.
+func (t *Table) Bytes() []byte {
+	buf := &bytes.Buffer{}
+	t.Write(buf) // Writing to a bytes.Buffer cannot fail
+	return buf.Bytes()
+}
+
+// Update sets the text of each entry in t whose key matches one of updates.
+// Updates with empty text are ignored, so that untranslated strings in an exported file do not erase existing ones.
+// It returns the number of entries whose text changed, and the keys of updates that are not in t.
+func (t *Table) Update(updates []Entry) (changed int, unknown []string) {
+	for _, u := range updates {
+		i, ok := t.index[u.Key]
+		if !ok {
+			unknown = append(unknown, u.Key)
+			continue
+		}
+		if u.Text == "" || t.Entries[i].Text == u.Text {
+			continue
+		}
+		t.Entries[i].Text = u.Text
+		changed++
+	}
+	return changed, unknown
+}
+
+// Diff describes how one table differs from another that it is compared against.
+type Diff struct {
+	Missing      []string // Keys in the base table that are not in the other
+	Extra        []string // Keys in the other table that are not in the base
+	Untranslated []string // Keys in both tables which have not been translated in the other
+}
+
+// Empty reports whether d found no differences.
+func (d Diff) Empty() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0 && len(d.Untranslated) == 0
+}
+
+// Compare compares other against base. Keys are listed in the order that they appear in their table.
+func Compare(base, other *Table) Diff {
+	d := Diff{}
+	for i, e := range base.Entries {
+		if base.index[e.Key] != i {
+			continue // Repeated key
+		}
+
+		o, ok := other.Lookup(e.Key)
+		if !ok {
+			d.Missing = append(d.Missing, e.Key)
+		} else if !o.Translated() {
+			d.Untranslated = append(d.Untranslated, e.Key)
+		}
+	}
+
+	for i, e := range other.Entries {
+		if other.index[e.Key] != i {
+			continue
+		}
+		if _, ok := base.Lookup(e.Key); !ok {
+			d.Extra = append(d.Extra, e.Key)
+		}
+	}
+
+	return d
+}
+
+// Untranslated returns the keys of the entries in t that have not been translated.
+func (t *Table) Untranslated() []string {
+	keys := []string{}
+	for i, e := range t.Entries {
+		if t.index[e.Key] == i && !e.Translated() {
+			keys = append(keys, e.Key)
+		}
+	}
+	return keys
+}
+
+// LanguageOf guesses the language of a file from its name, such as "de" for "data/lang/de.csv" or "de.po".
+func LanguageOf(filename string) string {
+	base := path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	return strings.TrimSuffix(base, path.Ext(base))
+}
@@ -0,0 +1,185 @@
+package lang
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testCSV = "\xef\xbb\xbfHello,Hallo\r\n" +
+	"\"Goodbye, friend\",\"Tschüss, Freund\",note\r\n" +
+	"Cancel,\r\n" +
+	"OK,OK\r\n"
+
+func parseTest(t *testing.T, data string) *Table {
+	t.Helper()
+
+	table, err := Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+func checkKeys(t *testing.T, what string, got []string, expected ...string) {
+	t.Helper()
+
+	if strings.Join(got, "|") != strings.Join(expected, "|") {
+		t.Fatalf("Got %s %q, expected %q", what, got, expected)
+	}
+}
+
+func TestParseAndWrite(t *testing.T) {
+	table := parseTest(t, testCSV)
+
+	if len(table.Entries) != 4 {
+		t.Fatalf("Got %d entries, expected 4", len(table.Entries))
+	}
+	e, ok := table.Lookup("Goodbye, friend")
+	if !ok || e.Text != "Tschüss, Freund" || len(e.Extra) != 1 || e.Extra[0] != "note" {
+		t.Fatalf("Got %+v, expected the quoted entry with one extra column", e)
+	}
+
+	if got := string(table.Bytes()); got != testCSV {
+		t.Fatalf("Got %q, expected %q", got, testCSV)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	table := parseTest(t, testCSV)
+
+	changed, unknown := table.Update([]Entry{
+		{Key: "Hello", Text: "Guten Tag"},
+		{Key: "Cancel", Text: "Abbrechen"},
+		{Key: "OK", Text: ""},
+		{Key: "Missing", Text: "Fehlt"},
+	})
+	if changed != 2 {
+		t.Fatalf("Got %d changed, expected 2", changed)
+	}
+	checkKeys(t, "unknown keys", unknown, "Missing")
+
+	if e, _ := table.Lookup("OK"); e.Text != "OK" {
+		t.Fatalf("Got %q, expected an empty update to be ignored", e.Text)
+	}
+	if !strings.Contains(string(table.Bytes()), "Cancel,Abbrechen\r\n") {
+		t.Fatalf("Got %q, expected the updated text", table.Bytes())
+	}
+}
+
+func TestCompare(t *testing.T) {
+	de := parseTest(t, testCSV)
+	pl := parseTest(t, "Hello,Cześć\nCancel,Anuluj\nQuit,\nNew,Nowy\n")
+
+	d := Compare(de, pl)
+	checkKeys(t, "missing keys", d.Missing, "Goodbye, friend", "OK")
+	checkKeys(t, "extra keys", d.Extra, "Quit", "New")
+	checkKeys(t, "untranslated keys", d.Untranslated)
+
+	checkKeys(t, "untranslated keys", de.Untranslated(), "Cancel", "OK")
+	checkKeys(t, "untranslated keys", Compare(de, de).Untranslated, "Cancel", "OK")
+}
+
+func TestPO(t *testing.T) {
+	table := parseTest(t, testCSV+"\"Line one\nLine \"\"two\"\"\",Zeile\r\n")
+
+	buf := &bytes.Buffer{}
+	if err := WritePO(buf, table, "de"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "msgid \"Line one\\nLine \\\"two\\\"\"\nmsgstr \"Zeile\"\n") {
+		t.Fatalf("Got %q, expected escaped strings", buf.String())
+	}
+
+	entries, err := ReadPO(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Entry{
+		{Key: "Hello", Text: "Hallo"},
+		{Key: "Goodbye, friend", Text: "Tschüss, Freund"},
+		{Key: "Cancel", Text: ""},
+		{Key: "OK", Text: ""},
+		{Key: "Line one\nLine \"two\"", Text: "Zeile"},
+	}
+	checkEntries(t, entries, expected)
+}
+
+func TestReadPO(t *testing.T) {
+	po := `# Translator comment
+msgid ""
+msgstr "Language: de\n"
+
+#: somewhere
+msgid "Hello"
+msgstr ""
+"Hal"
+"lo"
+#, fuzzy
+msgid "Fuzzy"
+msgstr "Unscharf"
+
+msgctxt "menu"
+msgid "File"
+msgstr "Datei"
+msgid "Plural"
+msgid_plural "Plurals"
+msgstr[0] "Mehrzahl"
+msgstr[1] "Mehrzahlen"
+msgid "Last"
+msgstr "Letzte"
+`
+	entries, err := ReadPO(strings.NewReader(po))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEntries(t, entries, []Entry{{Key: "Hello", Text: "Hallo"}, {Key: "Last", Text: "Letzte"}})
+
+	if _, err := ReadPO(strings.NewReader("msgid \"unterminated\n")); err == nil {
+		t.Fatal("Reading an invalid string succeeded")
+	}
+}
+
+func TestXLIFF(t *testing.T) {
+	table := parseTest(t, testCSV)
+
+	buf := &bytes.Buffer{}
+	if err := WriteXLIFF(buf, table, "en", "de"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `target-language="de"`) {
+		t.Fatalf("Got %q, expected the target language to be set", buf.String())
+	}
+
+	entries, err := ReadXLIFF(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEntries(t, entries, []Entry{{Key: "Hello", Text: "Hallo"}, {Key: "Goodbye, friend", Text: "Tschüss, Freund"}})
+}
+
+func checkEntries(t *testing.T, got []Entry, expected []Entry) {
+	t.Helper()
+
+	if len(got) != len(expected) {
+		t.Fatalf("Got %q, expected %q", got, expected)
+	}
+	for i := range expected {
+		if got[i].Key != expected[i].Key || got[i].Text != expected[i].Text {
+			t.Fatalf("Got %q, expected %q", got[i], expected[i])
+		}
+	}
+}
+
+func TestLanguageOf(t *testing.T) {
+	tests := map[string]string{
+		"data/lang/de.csv":   "de",
+		"translations/pl.po": "pl",
+		`C:\work\de.xlf`:     "de",
+	}
+	for filename, expected := range tests {
+		if got := LanguageOf(filename); got != expected {
+			t.Fatalf("Got %q, expected %q", got, expected)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package lang
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WritePO writes t to w as a gettext PO file for language, with each key as the msgid.
+// Untranslated entries are written with an empty msgstr.
+func WritePO(w io.Writer, t *Table, language string) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, `msgid ""`)
+	fmt.Fprintln(bw, `msgstr ""`)
+	fmt.Fprintf(bw, "%s\n", poQuote("Language: "+language+"\n"))
+	fmt.Fprintf(bw, "%s\n", poQuote("MIME-Version: 1.0\n"))
+	fmt.Fprintf(bw, "%s\n", poQuote("Content-Type: text/plain; charset=UTF-8\n"))
+	fmt.Fprintf(bw, "%s\n", poQuote("Content-Transfer-Encoding: 8bit\n"))
+
+	for i, e := range t.Entries {
+		if t.index[e.Key] != i || e.Key == "" {
+			continue // Repeated keys cannot be told apart, and an empty msgid is reserved for the header
+		}
+
+		text := e.Text
+		if !e.Translated() {
+			text = ""
+		}
+
+		fmt.Fprintln(bw)
+		fmt.Fprintf(bw, "msgid %s\n", poQuote(e.Key))
+		fmt.Fprintf(bw, "msgstr %s\n", poQuote(text))
+	}
+
+	return bw.Flush()
+}
+
+// poQuote quotes s as a PO string.
+func poQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// ReadPO reads the translations in a gettext PO file.
+// The header entry, fuzzy entries and entries with plural forms or a context are skipped.
+func ReadPO(r io.Reader) ([]Entry, error) {
+	entries := []Entry{}
+
+	var (
+		id, str     string
+		field       *string // String that continuation lines are appended to
+		fuzzy, skip bool
+		started     bool
+		afterStr    bool // A msgstr has been read for the current entry
+	)
+
+	flush := func() {
+		if started && id != "" && !fuzzy && !skip {
+			entries = append(entries, Entry{Key: id, Text: str})
+		}
+		id, str, field = "", "", nil
+		fuzzy, skip, started, afterStr = false, false, false, false
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "":
+			flush()
+
+		case strings.HasPrefix(line, "#,"):
+			if started {
+				flush()
+			}
+			if strings.Contains(line, "fuzzy") {
+				fuzzy = true
+			}
+
+		case strings.HasPrefix(line, "#"):
+			// Other comments, including obsolete entries
+
+		case strings.HasPrefix(line, `"`):
+			if field == nil {
+				return nil, fmt.Errorf("line %d: string outside of an entry", lineNum)
+			}
+			s, err := strconv.Unquote(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			*field += s
+
+		default:
+			keyword, value, _ := strings.Cut(line, " ")
+			if (keyword == "msgid" || keyword == "msgctxt") && afterStr {
+				flush() // Previous entry was not followed by a blank line
+			}
+
+			s, err := strconv.Unquote(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+
+			switch keyword {
+			case "msgid":
+				started = true
+				id, field = s, &id
+			case "msgstr":
+				str, field = s, &str
+				afterStr = true
+			case "msgctxt":
+				started, skip = true, true
+				field = new(string)
+			case "msgid_plural":
+				skip = true
+				field = new(string)
+			default:
+				if strings.HasPrefix(keyword, "msgstr[") {
+					skip, afterStr = true, true
+					field = new(string)
+					continue
+				}
+				return nil, fmt.Errorf("line %d: unknown keyword %q", lineNum, keyword)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+
+	return entries, nil
+}
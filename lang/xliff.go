@@ -0,0 +1,96 @@
+package lang
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type xliffDocument struct {
+	XMLName xml.Name    `xml:"urn:oasis:names:tc:xliff:document:1.2 xliff"`
+	Version string      `xml:"version,attr"`
+	Files   []xliffFile `xml:"file"`
+}
+
+type xliffFile struct {
+	Original       string      `xml:"original,attr"`
+	SourceLanguage string      `xml:"source-language,attr"`
+	TargetLanguage string      `xml:"target-language,attr,omitempty"`
+	Datatype       string      `xml:"datatype,attr"`
+	Units          []xliffUnit `xml:"body>trans-unit"`
+}
+
+type xliffUnit struct {
+	ID     string       `xml:"id,attr"`
+	Source string       `xml:"source"`
+	Target *xliffTarget `xml:"target"`
+}
+
+type xliffTarget struct {
+	State string `xml:"state,attr,omitempty"`
+	Text  string `xml:",chardata"`
+}
+
+// WriteXLIFF writes t to w as an XLIFF 1.2 document translating from sourceLanguage to language.
+// Each key is the source of a translation unit. Untranslated entries have an empty target marked as needing translation.
+func WriteXLIFF(w io.Writer, t *Table, sourceLanguage, language string) error {
+	file := xliffFile{
+		Original:       Path(language),
+		SourceLanguage: sourceLanguage,
+		TargetLanguage: language,
+		Datatype:       "plaintext",
+	}
+
+	for i, e := range t.Entries {
+		if t.index[e.Key] != i {
+			continue
+		}
+
+		target := &xliffTarget{State: "translated", Text: e.Text}
+		if !e.Translated() {
+			target = &xliffTarget{State: "needs-translation"}
+		}
+		file.Units = append(file.Units, xliffUnit{
+			ID:     strconv.Itoa(len(file.Units) + 1),
+			Source: e.Key,
+			Target: target,
+		})
+	}
+
+	doc := xliffDocument{Version: "1.2", Files: []xliffFile{file}}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ReadXLIFF reads the translations in an XLIFF 1.2 document.
+// Units without a target, or whose target is marked as needing translation, are skipped.
+func ReadXLIFF(r io.Reader) ([]Entry, error) {
+	doc := xliffDocument{}
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc.Version != "1.2" {
+		return nil, fmt.Errorf("unsupported XLIFF version %q", doc.Version)
+	}
+
+	entries := []Entry{}
+	for _, file := range doc.Files {
+		for _, unit := range file.Units {
+			if unit.Target == nil || unit.Target.State == "new" || unit.Target.State == "needs-translation" {
+				continue
+			}
+			entries = append(entries, Entry{Key: unit.Source, Text: unit.Target.Text})
+		}
+	}
+	return entries, nil
+}
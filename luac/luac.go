@@ -0,0 +1,272 @@
+// Package luac decodes precompiled Lua chunks.
+//
+// Chunks produced by luac for Lua 5.1 to 5.4, and by LuaJIT, are supported. Each starts with an
+// escape character followed by "Lua" or "LJ" and a version byte; the rest of the header describes
+// the sizes of the types used in the chunk. The header is followed by the main function prototype,
+// which contains the prototypes of the functions nested within it.
+//
+// Instructions are counted but not decoded, since their encoding differs between every version.
+package luac
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	signature       = "\x1bLua"
+	luajitSignature = "\x1bLJ"
+	luacData        = "\x19\x93\r\n\x1a\n" // Used by Lua 5.2 onwards to detect files that have been mangled in transit
+)
+
+var ErrNoMagicFound error = errors.New("lua bytecode signature not found")
+
+// ErrUnsupportedVersion is returned for chunks from a Lua version that this package cannot decode.
+var ErrUnsupportedVersion error = errors.New("unsupported lua bytecode version")
+
+// Header describes the platform that a chunk was compiled for.
+type Header struct {
+	LuaJIT          bool // Chunk was produced by LuaJIT rather than the reference implementation
+	Version         byte // 0x51 to 0x54 for Lua 5.1 to 5.4, or the bytecode version for LuaJIT
+	Format          byte // 0 for the official format
+	LittleEndian    bool
+	IntSize         int  // Size of a C int (Lua 5.1 to 5.3)
+	SizeTSize       int  // Size of a C size_t (Lua 5.1 to 5.3)
+	InstructionSize int  // Size of a virtual machine instruction
+	IntegerSize     int  // Size of a lua_Integer (Lua 5.3 onwards)
+	NumberSize      int  // Size of a lua_Number
+	Integral        bool // lua_Number is an integer type (Lua 5.1 and 5.2)
+	Stripped        bool // Debug information was removed (LuaJIT)
+	Flags           uint32
+}
+
+// VersionString returns a human-readable name for the version of Lua that the chunk is for.
+func (h Header) VersionString() string {
+	if h.LuaJIT {
+		return fmt.Sprintf("LuaJIT (bytecode version %d)", h.Version)
+	}
+	return fmt.Sprintf("Lua %d.%d", h.Version>>4, h.Version&0xf)
+}
+
+// ConstantKind is the type of a constant.
+type ConstantKind int
+
+const (
+	ConstantNil ConstantKind = iota
+	ConstantBool
+	ConstantNumber
+	ConstantInteger
+	ConstantString
+	ConstantTable // LuaJIT table template
+	ConstantCData // LuaJIT 64-bit integer or complex number
+)
+
+func (k ConstantKind) String() string {
+	switch k {
+	case ConstantNil:
+		return "nil"
+	case ConstantBool:
+		return "boolean"
+	case ConstantNumber:
+		return "number"
+	case ConstantInteger:
+		return "integer"
+	case ConstantString:
+		return "string"
+	case ConstantTable:
+		return "table"
+	case ConstantCData:
+		return "cdata"
+	default:
+		return fmt.Sprintf("ConstantKind(%d)", int(k))
+	}
+}
+
+// Constant is a constant used by a function.
+type Constant struct {
+	Kind  ConstantKind
+	Value interface{} // nil, bool, float64, int64 or string, depending on Kind
+}
+
+func (c Constant) String() string {
+	switch v := c.Value.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case nil:
+		return c.Kind.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Function is a function prototype.
+type Function struct {
+	Source          string // Name of the source file, if debug information is present
+	LineDefined     int
+	LastLineDefined int
+	NumParams       int
+	IsVararg        bool
+	MaxStackSize    int
+	Instructions    int // Number of instructions
+	Upvalues        int // Number of upvalues
+	Constants       []Constant
+	Locals          []string // Names of local variables, if debug information is present
+	Functions       []*Function
+}
+
+// Chunk is a precompiled Lua chunk.
+type Chunk struct {
+	Header
+	Main *Function
+}
+
+// Functions returns every function prototype in c, starting with the main function, in depth-first order.
+func (c Chunk) Functions() []*Function {
+	all := []*Function{}
+	var walk func(f *Function)
+	walk = func(f *Function) {
+		all = append(all, f)
+		for _, child := range f.Functions {
+			walk(child)
+		}
+	}
+	if c.Main != nil {
+		walk(c.Main)
+	}
+	return all
+}
+
+// Strings returns every distinct string constant in c, in the order that they first appear.
+func (c Chunk) Strings() []string {
+	seen := map[string]bool{}
+	strs := []string{}
+	for _, f := range c.Functions() {
+		for _, k := range f.Constants {
+			if s, ok := k.Value.(string); ok && !seen[s] {
+				seen[s] = true
+				strs = append(strs, s)
+			}
+		}
+	}
+	return strs
+}
+
+// IsBytecode reports whether data starts with a Lua or LuaJIT bytecode signature.
+func IsBytecode(data []byte) bool {
+	return hasPrefix(data, signature) || hasPrefix(data, luajitSignature)
+}
+
+func hasPrefix(data []byte, prefix string) bool {
+	return len(data) >= len(prefix) && string(data[:len(prefix)]) == prefix
+}
+
+// Parse reads a precompiled chunk from r.
+func Parse(r io.Reader) (Chunk, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Chunk{}, err
+	}
+
+	switch {
+	case hasPrefix(data, signature):
+		return parseLua(&reader{data: data, pos: len(signature)})
+	case hasPrefix(data, luajitSignature):
+		return parseLuaJIT(&reader{data: data, pos: len(luajitSignature)})
+	default:
+		return Chunk{}, ErrNoMagicFound
+	}
+}
+
+// reader decodes values from a chunk.
+// Once an error occurs, every further read returns a zero value and the error is kept in err.
+type reader struct {
+	data  []byte
+	pos   int
+	order binary.ByteOrder
+	err   error
+}
+
+// bytes returns the next n bytes.
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.data)-r.pos {
+		r.err = fmt.Errorf("unexpected end of chunk at offset %d reading %d bytes: %w", r.pos, n, io.ErrUnexpectedEOF)
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+func (r *reader) byte() byte {
+	b := r.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
+}
+
+// uint reads an unsigned integer of the given size in the chunk's byte order.
+func (r *reader) uint(size int) uint64 {
+	b := r.bytes(size)
+	if b == nil {
+		return 0
+	}
+
+	v := uint64(0)
+	for i := range b {
+		idx := i
+		if r.order == binary.LittleEndian {
+			idx = len(b) - 1 - i
+		}
+		v = v<<8 | uint64(b[idx])
+	}
+	return v
+}
+
+// int reads a signed integer of the given size in the chunk's byte order.
+func (r *reader) int(size int) int64 {
+	v := r.uint(size)
+	if size < 8 {
+		shift := uint(64 - 8*size)
+		return int64(v<<shift) >> shift
+	}
+	return int64(v)
+}
+
+// number reads a lua_Number of the given size.
+func (r *reader) number(size int, integral bool) float64 {
+	switch {
+	case integral:
+		return float64(r.int(size))
+	case size == 4:
+		return float64(math.Float32frombits(uint32(r.uint(4))))
+	case size == 8:
+		return math.Float64frombits(r.uint(8))
+	default:
+		r.fail("unsupported number size %d", size)
+		return 0
+	}
+}
+
+// count reads a non-negative element count and checks that it is plausible for the remaining data.
+func (r *reader) count(v int64) int {
+	if r.err == nil && (v < 0 || v > int64(len(r.data)-r.pos)) {
+		r.fail("implausible count %d", v)
+	}
+	if r.err != nil {
+		return 0
+	}
+	return int(v)
+}
+
+func (r *reader) fail(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf("offset %d: %s", r.pos, fmt.Sprintf(format, args...))
+	}
+}
@@ -0,0 +1,200 @@
+package luac
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// chunk builds test chunks.
+type chunk struct {
+	bytes.Buffer
+}
+
+func (c *chunk) u8(vals ...byte) *chunk {
+	c.Write(vals)
+	return c
+}
+
+func (c *chunk) i32(v int32) *chunk {
+	binary.Write(c, binary.LittleEndian, v)
+	return c
+}
+
+func (c *chunk) i64(v int64) *chunk {
+	binary.Write(c, binary.LittleEndian, v)
+	return c
+}
+
+func (c *chunk) f64(v float64) *chunk {
+	binary.Write(c, binary.LittleEndian, v)
+	return c
+}
+
+// str51 writes a Lua 5.1 string, with a 64-bit size_t.
+func (c *chunk) str51(s string) *chunk {
+	c.i64(int64(len(s) + 1))
+	c.WriteString(s)
+	return c.u8(0)
+}
+
+// varint writes a Lua 5.4 varint. Only values below 128 are needed here.
+func (c *chunk) varint(v byte) *chunk {
+	return c.u8(0x80 | v)
+}
+
+// str54 writes a Lua 5.4 string.
+func (c *chunk) str54(s string) *chunk {
+	c.varint(byte(len(s) + 1))
+	c.WriteString(s)
+	return c
+}
+
+// uleb writes a LuaJIT uleb128. Only values below 128 are needed here.
+func (c *chunk) uleb(v byte) *chunk {
+	return c.u8(v)
+}
+
+func checkFunction(t *testing.T, f *Function, source string, instructions int, constants ...Constant) {
+	t.Helper()
+
+	if f.Source != source || f.Instructions != instructions {
+		t.Fatalf("Got source %q and %d instructions, expected %q and %d", f.Source, f.Instructions, source, instructions)
+	}
+	if len(f.Constants) != len(constants) {
+		t.Fatalf("Got constants %v, expected %v", f.Constants, constants)
+	}
+	for i := range constants {
+		if f.Constants[i] != constants[i] {
+			t.Fatalf("Got constant %v, expected %v", f.Constants[i], constants[i])
+		}
+	}
+}
+
+func TestParseLua51(t *testing.T) {
+	c := &chunk{}
+	c.WriteString("\x1bLua")
+	c.u8(0x51, 0, 1, 4, 8, 4, 8, 0)
+
+	// Main function
+	c.str51("@test.lua").i32(0).i32(0).u8(0, 0, 2, 2)
+	c.i32(2).i32(0).i32(0)                     // Code
+	c.i32(2).u8(4).str51("hello").u8(3).f64(1) // Constants
+	c.i32(1)                                   // Prototypes
+	c.i64(0).i32(1).i32(1).u8(0, 0, 0, 2)      // Nested function, without a source name
+	c.i32(1).i32(0)                            // Code
+	c.i32(1).u8(1, 1)                          // Constants
+	c.i32(0)                                   // Prototypes
+	c.i32(0).i32(0).i32(0)                     // Debug information
+	c.i32(2).i32(1).i32(1)                     // Line info
+	c.i32(1).str51("x").i32(0).i32(2)          // Local variables
+	c.i32(0)                                   // Upvalues
+
+	parsed, err := Parse(bytes.NewReader(c.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.VersionString() != "Lua 5.1" || !parsed.LittleEndian || parsed.SizeTSize != 8 || parsed.NumberSize != 8 {
+		t.Fatalf("Got header %+v", parsed.Header)
+	}
+
+	checkFunction(t, parsed.Main, "@test.lua", 2, Constant{ConstantString, "hello"}, Constant{ConstantNumber, 1.0})
+	if len(parsed.Main.Locals) != 1 || parsed.Main.Locals[0] != "x" {
+		t.Fatalf("Got locals %v, expected [x]", parsed.Main.Locals)
+	}
+
+	if len(parsed.Main.Functions) != 1 {
+		t.Fatalf("Got %d nested functions, expected 1", len(parsed.Main.Functions))
+	}
+	checkFunction(t, parsed.Main.Functions[0], "@test.lua", 1, Constant{ConstantBool, true})
+
+	if len(parsed.Functions()) != 2 {
+		t.Fatalf("Got %d functions, expected 2", len(parsed.Functions()))
+	}
+}
+
+func TestParseLua54(t *testing.T) {
+	c := &chunk{}
+	c.WriteString("\x1bLua")
+	c.u8(0x54, 0)
+	c.WriteString(luacData)
+	c.u8(4, 8, 8).i64(luacInt).f64(luacNum).u8(1)
+
+	c.str54("@test.lua").varint(0).varint(0).u8(0, 1, 2)
+	c.varint(1).i32(0)                                                 // Code
+	c.varint(4).u8(4).str54("hi").u8(3).i64(42).u8(17).u8(19).f64(0.5) // Constants
+	c.varint(1).u8(1, 0, 0)                                            // Upvalues
+	c.varint(0)                                                        // Prototypes
+	c.varint(0).varint(0).varint(0).varint(1).str54("_ENV")            // Debug information
+
+	parsed, err := Parse(bytes.NewReader(c.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.VersionString() != "Lua 5.4" || !parsed.LittleEndian || parsed.IntegerSize != 8 {
+		t.Fatalf("Got header %+v", parsed.Header)
+	}
+	checkFunction(t, parsed.Main, "@test.lua", 1,
+		Constant{ConstantString, "hi"},
+		Constant{ConstantInteger, int64(42)},
+		Constant{ConstantBool, true},
+		Constant{ConstantNumber, 0.5},
+	)
+	if parsed.Main.Upvalues != 1 || !parsed.Main.IsVararg {
+		t.Fatalf("Got %d upvalues and vararg %v, expected 1 and true", parsed.Main.Upvalues, parsed.Main.IsVararg)
+	}
+	if strs := parsed.Strings(); len(strs) != 1 || strs[0] != "hi" {
+		t.Fatalf("Got strings %v, expected [hi]", strs)
+	}
+}
+
+func TestParseLuaJIT(t *testing.T) {
+	child := &chunk{}
+	child.u8(0, 1, 2, 0).uleb(1).uleb(1).uleb(1)
+	child.i32(0)                         // Code
+	child.uleb(5 + 3).WriteString("abc") // String constant
+	child.uleb(7 << 1)                   // Integer constant
+
+	main := &chunk{}
+	main.u8(luajitProtoVararg, 0, 2, 0).uleb(1).uleb(1).uleb(2)
+	main.i32(0).i32(0)                               // Code
+	main.uleb(kgcChild)                              // Child prototype
+	main.u8(0<<1|1).u8(0x80, 0x80, 0xe0, 0xff, 0x03) // 1.5, as a 33-bit low word of 0 and a uleb128 high word of 0x3ff80000
+
+	c := &chunk{}
+	c.WriteString("\x1bLJ")
+	c.u8(2).uleb(luajitFlagStrip)
+	c.uleb(byte(child.Len())).u8(child.Bytes()...)
+	c.uleb(byte(main.Len())).u8(main.Bytes()...)
+	c.uleb(0)
+
+	parsed, err := Parse(bytes.NewReader(c.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !parsed.LuaJIT || parsed.Version != 2 || !parsed.Stripped {
+		t.Fatalf("Got header %+v", parsed.Header)
+	}
+	if len(parsed.Main.Functions) != 1 {
+		t.Fatalf("Got %d nested functions, expected 1", len(parsed.Main.Functions))
+	}
+	checkFunction(t, parsed.Main.Functions[0], "", 1, Constant{ConstantString, "abc"}, Constant{ConstantInteger, int64(7)})
+	checkFunction(t, parsed.Main, "", 2, Constant{ConstantNumber, 1.5})
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"no signature":    "return 1",
+		"unknown version": "\x1bLua\x50\x00",
+		"truncated":       "\x1bLua\x51\x00\x01\x04\x08",
+	}
+
+	for name, data := range tests {
+		if _, err := Parse(bytes.NewReader([]byte(data))); err == nil {
+			t.Fatalf("%s: parsing succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package luac
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Flags in the header of a LuaJIT chunk.
+const (
+	luajitFlagBigEndian = 0x01
+	luajitFlagStrip     = 0x02
+	luajitFlagFFI       = 0x04
+	luajitFlagFR2       = 0x08
+
+	luajitProtoVararg = 0x02
+)
+
+// Types of garbage-collected constants in a LuaJIT prototype.
+const (
+	kgcChild = iota
+	kgcTable
+	kgcI64
+	kgcU64
+	kgcComplex
+	kgcString // Strings have a type of kgcString plus their length
+)
+
+// Types of constants in a LuaJIT table template.
+const (
+	ktabNil = iota
+	ktabFalse
+	ktabTrue
+	ktabInt
+	ktabNum
+	ktabString
+)
+
+// luajitReader decodes chunks produced by LuaJIT.
+type luajitReader struct {
+	*reader
+	h Header
+}
+
+// parseLuaJIT decodes a chunk whose signature has already been read by r.
+// LuaJIT writes nested prototypes before the prototypes that contain them, so the main function comes last.
+func parseLuaJIT(r *reader) (Chunk, error) {
+	l := &luajitReader{reader: r}
+	h := &l.h
+	h.LuaJIT = true
+	h.InstructionSize = 4
+	h.NumberSize = 8
+
+	h.Version = r.byte()
+	h.Flags = uint32(l.uleb())
+	h.LittleEndian = h.Flags&luajitFlagBigEndian == 0
+	h.Stripped = h.Flags&luajitFlagStrip != 0
+
+	r.order = binary.LittleEndian
+	if !h.LittleEndian {
+		r.order = binary.BigEndian
+	}
+
+	source := ""
+	if !h.Stripped {
+		source = string(r.bytes(l.count(l.uleb())))
+	}
+
+	stack := []*Function{}
+	for r.err == nil {
+		length := l.count(l.uleb())
+		if length == 0 {
+			break
+		}
+
+		end := r.pos + length
+		f := l.proto(source, &stack)
+		if r.err == nil && r.pos > end {
+			r.fail("prototype is longer than its recorded length")
+		}
+		r.pos = end
+
+		stack = append(stack, f)
+	}
+
+	if r.err != nil {
+		return Chunk{Header: *h}, r.err
+	}
+	if len(stack) != 1 {
+		r.fail("expected one main prototype, found %d", len(stack))
+		return Chunk{Header: *h}, r.err
+	}
+
+	return Chunk{Header: *h, Main: stack[0]}, nil
+}
+
+// uleb reads an unsigned LEB128 number.
+func (l *luajitReader) uleb() int64 {
+	v := int64(0)
+	for shift := uint(0); shift < 64; shift += 7 {
+		b := l.byte()
+		v |= int64(b&0x7f) << shift
+		if b&0x80 == 0 || l.err != nil {
+			return v
+		}
+	}
+	l.fail("uleb128 is too long")
+	return 0
+}
+
+// uleb33 reads the 33-bit LEB128 number used for numeric constants, whose lowest bit is a flag.
+func (l *luajitReader) uleb33() (uint32, bool) {
+	b := l.byte()
+	flag := b&1 != 0
+	v := uint32(b >> 1)
+	if v >= 0x40 {
+		v &= 0x3f
+		for shift := uint(6); l.err == nil; shift += 7 {
+			b = l.byte()
+			v |= uint32(b&0x7f) << shift
+			if b&0x80 == 0 {
+				break
+			}
+		}
+	}
+	return v, flag
+}
+
+// proto reads a prototype, taking the prototypes of any child functions from stack.
+func (l *luajitReader) proto(source string, stack *[]*Function) *Function {
+	f := &Function{Source: source}
+
+	flags := l.byte()
+	f.IsVararg = flags&luajitProtoVararg != 0
+	f.NumParams = int(l.byte())
+	f.MaxStackSize = int(l.byte())
+	f.Upvalues = int(l.byte())
+	numKGC := l.count(l.uleb())
+	numKN := l.count(l.uleb())
+	f.Instructions = l.count(l.uleb())
+
+	if !l.h.Stripped {
+		if debugLen := l.uleb(); debugLen > 0 {
+			f.LineDefined = int(l.uleb())
+			f.LastLineDefined = f.LineDefined + int(l.uleb())
+		}
+	}
+
+	l.bytes(f.Instructions * l.h.InstructionSize)
+	l.bytes(f.Upvalues * 2)
+
+	children := []*Function{}
+	for i := 0; i < numKGC && l.err == nil; i++ {
+		t := l.uleb()
+		switch {
+		case t >= kgcString:
+			f.Constants = append(f.Constants, Constant{ConstantString, string(l.bytes(l.count(t - kgcString)))})
+		case t == kgcChild:
+			if len(*stack) == 0 {
+				l.fail("child prototype missing")
+				continue
+			}
+			children = append(children, (*stack)[len(*stack)-1])
+			*stack = (*stack)[:len(*stack)-1]
+		case t == kgcTable:
+			l.table()
+			f.Constants = append(f.Constants, Constant{ConstantTable, nil})
+		case t == kgcI64, t == kgcU64:
+			l.uleb()
+			l.uleb()
+			f.Constants = append(f.Constants, Constant{ConstantCData, nil})
+		case t == kgcComplex:
+			for j := 0; j < 4; j++ {
+				l.uleb()
+			}
+			f.Constants = append(f.Constants, Constant{ConstantCData, nil})
+		}
+	}
+
+	// Children are popped in the reverse of the order they were written
+	for i := len(children) - 1; i >= 0; i-- {
+		f.Functions = append(f.Functions, children[i])
+	}
+
+	for i := 0; i < numKN && l.err == nil; i++ {
+		lo, isNum := l.uleb33()
+		if isNum {
+			hi := uint32(l.uleb())
+			f.Constants = append(f.Constants, Constant{ConstantNumber, math.Float64frombits(uint64(hi)<<32 | uint64(lo))})
+		} else {
+			f.Constants = append(f.Constants, Constant{ConstantInteger, int64(int32(lo))})
+		}
+	}
+
+	// The rest of the prototype is debug information, which the caller skips
+	return f
+}
+
+// table skips a table template constant.
+func (l *luajitReader) table() {
+	numArray := l.count(l.uleb())
+	numHash := l.count(l.uleb())
+	for i := 0; i < numArray+2*numHash && l.err == nil; i++ {
+		t := l.uleb()
+		switch {
+		case t >= ktabString:
+			l.bytes(l.count(t - ktabString))
+		case t == ktabInt:
+			l.uleb()
+		case t == ktabNum:
+			l.uleb()
+			l.uleb()
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package luac
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	luacInt = 0x5678 // Integer stored in Lua 5.3 and 5.4 headers to check the integer format
+	luacNum = 370.5  // Number stored in Lua 5.3 and 5.4 headers to check the float format
+)
+
+// luaReader decodes chunks produced by the reference implementation of Lua.
+type luaReader struct {
+	*reader
+	h Header
+}
+
+// parseLua decodes a chunk whose signature has already been read by r.
+func parseLua(r *reader) (Chunk, error) {
+	l := &luaReader{reader: r}
+	h := &l.h
+
+	h.Version = r.byte()
+	h.Format = r.byte()
+	if r.err != nil {
+		return Chunk{}, r.err
+	}
+
+	switch h.Version {
+	case 0x51, 0x52:
+		h.LittleEndian = r.byte() == 1
+		h.IntSize = int(r.byte())
+		h.SizeTSize = int(r.byte())
+		h.InstructionSize = int(r.byte())
+		h.NumberSize = int(r.byte())
+		h.Integral = r.byte() != 0
+		if h.Version == 0x52 && string(r.bytes(len(luacData))) != luacData {
+			r.fail("corrupted chunk header")
+		}
+
+		r.order = binary.BigEndian
+		if h.LittleEndian {
+			r.order = binary.LittleEndian
+		}
+
+	case 0x53, 0x54:
+		if string(r.bytes(len(luacData))) != luacData {
+			r.fail("corrupted chunk header")
+		}
+		if h.Version == 0x53 {
+			h.IntSize = int(r.byte())
+			h.SizeTSize = int(r.byte())
+		}
+		h.InstructionSize = int(r.byte())
+		h.IntegerSize = int(r.byte())
+		h.NumberSize = int(r.byte())
+
+		// The byte order is not recorded, so work it out from the test integer
+		start := r.pos
+		r.order = binary.LittleEndian
+		if r.int(h.IntegerSize) != luacInt {
+			r.pos = start
+			r.order = binary.BigEndian
+			if r.int(h.IntegerSize) != luacInt {
+				r.fail("integer format mismatch")
+			}
+		}
+		h.LittleEndian = r.order == binary.LittleEndian
+
+		if r.number(h.NumberSize, false) != luacNum {
+			r.fail("float format mismatch")
+		}
+		r.byte() // Number of upvalues of the main function
+
+	default:
+		return Chunk{Header: *h}, fmt.Errorf("%w: 0x%02x", ErrUnsupportedVersion, h.Version)
+	}
+
+	if r.err != nil {
+		return Chunk{Header: *h}, r.err
+	}
+
+	main := l.function("")
+	if r.err != nil {
+		return Chunk{Header: *h}, r.err
+	}
+
+	return Chunk{Header: *h, Main: main}, nil
+}
+
+// varint reads an unsigned integer in the variable-length encoding used by Lua 5.4.
+func (l *luaReader) varint() int64 {
+	v := int64(0)
+	for i := 0; i < 10; i++ {
+		b := l.byte()
+		v = v<<7 | int64(b&0x7f)
+		if b&0x80 != 0 || l.err != nil {
+			return v
+		}
+	}
+	l.fail("varint is too long")
+	return 0
+}
+
+// cint reads a C int, which Lua 5.4 encodes as a varint.
+func (l *luaReader) cint() int64 {
+	if l.h.Version == 0x54 {
+		return l.varint()
+	}
+	return l.int(l.h.IntSize)
+}
+
+// str reads a string. Missing strings (such as stripped source names) are returned as "".
+func (l *luaReader) str() string {
+	var size int64
+	switch l.h.Version {
+	case 0x51, 0x52:
+		size = int64(l.uint(l.h.SizeTSize))
+	case 0x53:
+		size = int64(l.byte())
+		if size == 0xff {
+			size = int64(l.uint(l.h.SizeTSize))
+		}
+	default:
+		size = l.varint()
+	}
+
+	if size == 0 {
+		return ""
+	}
+
+	b := l.bytes(l.count(size - 1))
+	if l.h.Version <= 0x52 {
+		l.byte() // Lua 5.1 and 5.2 include the terminating nul in the size
+	}
+	return string(b)
+}
+
+// function reads a function prototype. parentSource is used for functions which do not record their own source.
+func (l *luaReader) function(parentSource string) *Function {
+	f := &Function{}
+	v := l.h.Version
+
+	if v != 0x52 {
+		f.Source = l.str()
+	}
+	f.LineDefined = int(l.cint())
+	f.LastLineDefined = int(l.cint())
+	if v == 0x51 {
+		f.Upvalues = int(l.byte())
+	}
+	f.NumParams = int(l.byte())
+	f.IsVararg = l.byte() != 0
+	f.MaxStackSize = int(l.byte())
+
+	f.Instructions = l.count(l.cint())
+	l.bytes(f.Instructions * l.h.InstructionSize)
+
+	l.constants(f)
+
+	switch v {
+	case 0x51:
+		l.functions(f)
+	case 0x52:
+		l.functions(f)
+		l.upvalues(f, 2)
+	case 0x53:
+		l.upvalues(f, 2)
+		l.functions(f)
+	default:
+		l.upvalues(f, 3)
+		l.functions(f)
+	}
+
+	if v == 0x52 {
+		f.Source = l.str()
+	}
+	if f.Source == "" {
+		f.Source = parentSource
+	}
+	for _, child := range f.Functions {
+		if child.Source == "" {
+			child.Source = f.Source
+		}
+	}
+
+	l.debug(f)
+
+	return f
+}
+
+// constants reads a function's constant table.
+func (l *luaReader) constants(f *Function) {
+	n := l.count(l.cint())
+	for i := 0; i < n && l.err == nil; i++ {
+		t := l.byte()
+
+		var k Constant
+		switch {
+		case t == 0:
+			k = Constant{ConstantNil, nil}
+		case t == 1 && l.h.Version < 0x54:
+			k = Constant{ConstantBool, l.byte() != 0}
+		case t == 1 && l.h.Version == 0x54:
+			k = Constant{ConstantBool, false}
+		case t == 17 && l.h.Version == 0x54:
+			k = Constant{ConstantBool, true}
+		case t == 3 && l.h.Version <= 0x53, t == 19 && l.h.Version == 0x54:
+			k = Constant{ConstantNumber, l.number(l.h.NumberSize, l.h.Integral)}
+		case t == 19 && l.h.Version == 0x53, t == 3 && l.h.Version == 0x54:
+			k = Constant{ConstantInteger, l.int(l.h.IntegerSize)}
+		case t == 4, t == 20 && l.h.Version >= 0x53:
+			k = Constant{ConstantString, l.str()}
+		default:
+			l.fail("unknown constant type %d", t)
+		}
+
+		f.Constants = append(f.Constants, k)
+	}
+}
+
+// upvalues reads a function's upvalue descriptions, which are size bytes each.
+func (l *luaReader) upvalues(f *Function, size int) {
+	f.Upvalues = l.count(l.cint())
+	l.bytes(f.Upvalues * size)
+}
+
+// functions reads the prototypes of the functions nested in f.
+func (l *luaReader) functions(f *Function) {
+	n := l.count(l.cint())
+	for i := 0; i < n && l.err == nil; i++ {
+		f.Functions = append(f.Functions, l.function(f.Source))
+	}
+}
+
+// debug reads a function's debug information, keeping only the names of its local variables.
+func (l *luaReader) debug(f *Function) {
+	if l.h.Version == 0x54 {
+		l.bytes(l.count(l.varint())) // Line deltas
+		n := l.count(l.varint())     // Absolute line info
+		for i := 0; i < n && l.err == nil; i++ {
+			l.varint()
+			l.varint()
+		}
+	} else {
+		l.bytes(l.count(l.cint()) * l.h.IntSize)
+	}
+
+	n := l.count(l.cint())
+	for i := 0; i < n && l.err == nil; i++ {
+		f.Locals = append(f.Locals, l.str())
+		l.cint() // Start and end of the variable's scope
+		l.cint()
+	}
+
+	n = l.count(l.cint())
+	for i := 0; i < n && l.err == nil; i++ {
+		l.str() // Upvalue names
+	}
+}
@@ -0,0 +1,308 @@
+package mod
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// StateDir is the directory within the game directory where jhmod keeps track of mods.
+	StateDir = "jhmod"
+	// RecordFile is the name of the install record in StateDir.
+	RecordFile = "installed.json"
+	// DisabledDir is the directory in StateDir that disabled mods are moved to, so that the game does not load them.
+	DisabledDir = "disabled"
+)
+
+// ErrNotInstalled is returned for a mod that is not in the mods directory or among the disabled mods.
+var ErrNotInstalled = errors.New("mod is not installed")
+
+// Installed is the record of a mod that was installed by jhmod.
+type Installed struct {
+	Name    string
+	Version string `json:",omitempty"`
+	Source  string
+	Time    time.Time
+	// Files are the paths of the installed files, relative to the mod's folder and separated by slashes.
+	Files []string
+}
+
+// Record lists the mods that were installed by jhmod, so that they can be removed cleanly.
+type Record struct {
+	Mods []Installed
+}
+
+func recordPath(gameDir string) string {
+	return filepath.Join(gameDir, StateDir, RecordFile)
+}
+
+// LoadRecord reads the install record of gameDir. If there is none, an empty record is returned.
+func LoadRecord(gameDir string) (*Record, error) {
+	data, err := os.ReadFile(recordPath(gameDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Record{Mods: []Installed{}}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	r := &Record{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("%s: %w", recordPath(gameDir), err)
+	}
+	return r, nil
+}
+
+// Save writes r as the install record of gameDir.
+func (r *Record) Save(gameDir string) error {
+	sort.Slice(r.Mods, func(i, j int) bool {
+		return r.Mods[i].Name < r.Mods[j].Name
+	})
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(gameDir, StateDir), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(recordPath(gameDir), append(data, '\n'), 0644)
+}
+
+// Find returns the record of the mod called name, or nil if it was not installed by jhmod.
+func (r *Record) Find(name string) *Installed {
+	for i := range r.Mods {
+		if r.Mods[i].Name == name {
+			return &r.Mods[i]
+		}
+	}
+	return nil
+}
+
+func (r *Record) remove(name string) {
+	mods := []Installed{}
+	for _, m := range r.Mods {
+		if m.Name != name {
+			mods = append(mods, m)
+		}
+	}
+	r.Mods = mods
+}
+
+// disabledDir returns the folder that the mod called name is moved to when it is disabled.
+func disabledDir(gameDir, name string) string {
+	return filepath.Join(gameDir, StateDir, DisabledDir, name)
+}
+
+// Locate returns the folder of the mod called name in gameDir and whether it is enabled.
+// ErrNotInstalled is returned if there is no such mod.
+func Locate(gameDir, name string) (string, bool, error) {
+	if err := ValidName(name); err != nil {
+		return "", false, err
+	}
+
+	for _, dir := range []string{Dir(gameDir, name), disabledDir(gameDir, name)} {
+		info, err := os.Stat(dir)
+		if err == nil && info.IsDir() {
+			return dir, dir == Dir(gameDir, name), nil
+		} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", false, err
+		}
+	}
+	return "", false, fmt.Errorf("%s: %w", name, ErrNotInstalled)
+}
+
+// InstallOptions changes how Install behaves.
+type InstallOptions struct {
+	// Replace allows a mod that was installed by jhmod to be replaced, for example by a newer version.
+	Replace bool
+	// IgnoreConflicts installs the mod even if it has files in common with an enabled mod.
+	IgnoreConflicts bool
+}
+
+// Install copies the mod in src into the mods directory of gameDir, and adds it to the install record.
+//
+// Install fails if a mod with the same name is already installed, unless it was installed by jhmod
+// and opts.Replace is set, or if the mod has a file in common with another enabled mod, unless
+// opts.IgnoreConflicts is set.
+func Install(gameDir string, src *Source, opts InstallOptions) (Installed, error) {
+	if err := ValidName(src.Name); err != nil {
+		return Installed{}, err
+	}
+	for _, f := range src.Files {
+		if err := checkPath(f); err != nil {
+			return Installed{}, err
+		}
+	}
+
+	record, err := LoadRecord(gameDir)
+	if err != nil {
+		return Installed{}, err
+	}
+
+	dir, enabled, err := Locate(gameDir, src.Name)
+	switch {
+	case errors.Is(err, ErrNotInstalled):
+		dir, enabled = Dir(gameDir, src.Name), true
+	case err != nil:
+		return Installed{}, err
+	case record.Find(src.Name) == nil:
+		return Installed{}, fmt.Errorf("%s was not installed by jhmod, so it will not be replaced", dir)
+	case !opts.Replace:
+		return Installed{}, fmt.Errorf("%s is already installed", src.Name)
+	}
+
+	if enabled && !opts.IgnoreConflicts {
+		conflicts, err := findConflicts(gameDir, src.Name, src.Files)
+		if err != nil {
+			return Installed{}, err
+		}
+		if len(conflicts) > 0 {
+			return Installed{}, conflictError(conflicts)
+		}
+	}
+
+	if old := record.Find(src.Name); old != nil {
+		if _, err := removeFiles(dir, old.Files); err != nil {
+			return Installed{}, err
+		}
+		record.remove(src.Name)
+	}
+
+	installed := Installed{
+		Name:    src.Name,
+		Version: src.Manifest.Version,
+		Source:  src.path,
+		Time:    time.Now().UTC(),
+		Files:   []string{},
+	}
+
+	for _, f := range src.Files {
+		outPath, err := localPath(dir, f)
+		if err == nil {
+			err = copyFile(src, f, outPath)
+		}
+		if err != nil {
+			// Record what was copied, so that it can be uninstalled
+			record.Mods = append(record.Mods, installed)
+			if saveErr := record.Save(gameDir); saveErr != nil {
+				return installed, fmt.Errorf("%s: %w (and the copied files could not be recorded: %v)", f, err, saveErr)
+			}
+			return installed, fmt.Errorf("%s: %w", f, err)
+		}
+		installed.Files = append(installed.Files, f)
+	}
+
+	record.Mods = append(record.Mods, installed)
+	return installed, record.Save(gameDir)
+}
+
+func copyFile(src *Source, name, outPath string) error {
+	r, err := src.Open(name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// removeFiles removes files from dir, then any folders that are left empty, including dir itself.
+// The paths of files in dir that were not removed are returned.
+func removeFiles(dir string, files []string) ([]string, error) {
+	for _, f := range files {
+		p, err := localPath(dir, f)
+		if err != nil {
+			return nil, err
+		}
+		err = os.Remove(p)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	left := []string{}
+	dirs := []string{}
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			dirs = append(dirs, p)
+		} else {
+			rel, _ := filepath.Rel(dir, p)
+			left = append(left, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return left, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Deepest first, so that folders are empty by the time their parents are removed
+	sort.Slice(dirs, func(i, j int) bool {
+		return len(dirs[i]) > len(dirs[j])
+	})
+	for _, d := range dirs {
+		os.Remove(d) // Fails harmlessly for folders that are not empty
+	}
+	return left, nil
+}
+
+// Uninstall removes the files that were installed for the mod called name, whether it is enabled or not,
+// and removes it from the install record. Files in the mod's folder that were not installed by jhmod,
+// such as reference files, are left in place, and their paths are returned.
+func Uninstall(gameDir, name string) ([]string, error) {
+	record, err := LoadRecord(gameDir)
+	if err != nil {
+		return nil, err
+	}
+	installed := record.Find(name)
+	if installed == nil {
+		return nil, fmt.Errorf("%s was not installed by jhmod, so it cannot be uninstalled", name)
+	}
+
+	dir, _, err := Locate(gameDir, name)
+	if errors.Is(err, ErrNotInstalled) {
+		// Already deleted by hand
+		record.remove(name)
+		return []string{}, record.Save(gameDir)
+	} else if err != nil {
+		return nil, err
+	}
+
+	left, err := removeFiles(dir, installed.Files)
+	if err != nil {
+		return nil, err
+	}
+	record.remove(name)
+	return left, record.Save(gameDir)
+}
+
+// conflictError describes conflicts as an error.
+func conflictError(conflicts []Conflict) error {
+	lines := []string{}
+	for _, c := range conflicts {
+		lines = append(lines, fmt.Sprintf("%s is in %s", c.Path, strings.Join(c.Mods, ", ")))
+	}
+	return fmt.Errorf("mod has files in common with other enabled mods:\n  %s", strings.Join(lines, "\n  "))
+}
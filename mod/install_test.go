@@ -0,0 +1,115 @@
+package mod
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func install(t *testing.T, gameDir, path string, opts InstallOptions) (Installed, error) {
+	src, err := OpenSource(path)
+	if err != nil {
+		t.Fatalf("OpenSource(%s) failed: %v", path, err)
+	}
+	defer src.Close()
+	return Install(gameDir, src, opts)
+}
+
+func TestInstallUninstall(t *testing.T) {
+	gameDir := t.TempDir()
+	zipPath := writeZip(t, "coolestmod.zip", map[string]string{
+		"coolestmod/main.lua":     "-- v1",
+		"coolestmod/mod.json":     `{"Name":"coolestmod","Version":"1.0"}`,
+		"coolestmod/lib/util.lua": "-- util",
+	})
+
+	installed, err := install(t, gameDir, zipPath, InstallOptions{})
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if installed.Version != "1.0" || strings.Join(installed.Files, " ") != "lib/util.lua main.lua mod.json" {
+		t.Fatalf("Got %+v, expected version 1.0 and three files", installed)
+	}
+	if data, _ := os.ReadFile(filepath.Join(gameDir, "mods", "coolestmod", "main.lua")); string(data) != "-- v1" {
+		t.Fatalf("Got %q, expected %q", data, "-- v1")
+	}
+
+	if _, err := install(t, gameDir, zipPath, InstallOptions{}); err == nil {
+		t.Fatalf("Got no error installing a mod twice")
+	}
+
+	// Replacing removes the files that the new version no longer has
+	v2 := writeZip(t, "coolestmod.zip", map[string]string{
+		"main.lua": "-- v2",
+		"mod.json": `{"Name":"coolestmod","Version":"2.0"}`,
+	})
+	if _, err := install(t, gameDir, v2, InstallOptions{Replace: true}); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(gameDir, "mods", "coolestmod", "lib")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Got %v, expected the old lib folder to be removed", err)
+	}
+	record, err := LoadRecord(gameDir)
+	if err != nil {
+		t.Fatalf("LoadRecord failed: %v", err)
+	}
+	if len(record.Mods) != 1 || record.Mods[0].Version != "2.0" {
+		t.Fatalf("Got %+v, expected one mod at version 2.0", record.Mods)
+	}
+
+	// Files added by the user are left behind
+	writeDir(t, Dir(gameDir, "coolestmod"), map[string]string{"notes.txt": "mine"})
+	left, err := Uninstall(gameDir, "coolestmod")
+	if err != nil {
+		t.Fatalf("Uninstall failed: %v", err)
+	}
+	if strings.Join(left, " ") != "notes.txt" {
+		t.Fatalf("Got %v, expected [notes.txt]", left)
+	}
+	if _, err := os.Stat(filepath.Join(gameDir, "mods", "coolestmod", "main.lua")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Got %v, expected main.lua to be removed", err)
+	}
+	if record, _ := LoadRecord(gameDir); len(record.Mods) != 0 {
+		t.Fatalf("Got %+v, expected an empty record", record.Mods)
+	}
+
+	if _, err := Uninstall(gameDir, "coolestmod"); err == nil {
+		t.Fatalf("Got no error uninstalling a mod twice")
+	}
+}
+
+func TestInstallRefusesUnmanaged(t *testing.T) {
+	gameDir := t.TempDir()
+	writeDir(t, Dir(gameDir, "handmade"), map[string]string{"main.lua": "-- mine"})
+
+	src := writeZip(t, "handmade.zip", map[string]string{"main.lua": "-- theirs"})
+	if _, err := install(t, gameDir, src, InstallOptions{Replace: true}); err == nil {
+		t.Fatalf("Got no error replacing a mod that was not installed by jhmod")
+	}
+	if data, _ := os.ReadFile(filepath.Join(Dir(gameDir, "handmade"), "main.lua")); string(data) != "-- mine" {
+		t.Fatalf("Got %q, expected the mod to be left alone", data)
+	}
+	if _, err := Uninstall(gameDir, "handmade"); err == nil {
+		t.Fatalf("Got no error uninstalling a mod that was not installed by jhmod")
+	}
+}
+
+func TestInstallConflicts(t *testing.T) {
+	gameDir := t.TempDir()
+	writeDir(t, Dir(gameDir, "first"), map[string]string{"main.lua": "", "data/items.lua": ""})
+
+	src := writeZip(t, "second.zip", map[string]string{"main.lua": "", "data/items.lua": "", "data/other.lua": ""})
+	_, err := install(t, gameDir, src, InstallOptions{})
+	if err == nil || !strings.Contains(err.Error(), "data/items.lua is in second, first") {
+		t.Fatalf("Got %v, expected a conflict on data/items.lua", err)
+	}
+	if _, _, err := Locate(gameDir, "second"); !errors.Is(err, ErrNotInstalled) {
+		t.Fatalf("Got %v, expected nothing to be installed", err)
+	}
+
+	if _, err := install(t, gameDir, src, InstallOptions{IgnoreConflicts: true}); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+}
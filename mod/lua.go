@@ -0,0 +1,292 @@
+package mod
+
+import (
+	"fmt"
+)
+
+// LuaSyntaxError is a problem found by CheckLua.
+type LuaSyntaxError struct {
+	Line int
+	Msg  string
+}
+
+func (e *LuaSyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// block is a construct that CheckLua is waiting to see the end of.
+type block struct {
+	kind string // A keyword such as "function", or an opening bracket
+	line int
+	body bool // The do of a while or for loop has been seen
+}
+
+// closer returns the token that ends b.
+func (b block) closer() string {
+	if b.body {
+		return "end"
+	}
+	return closers[b.kind]
+}
+
+// closers gives the token that ends each kind of block. A while or for loop is ended by do, which
+// then starts the loop's body.
+var closers = map[string]string{
+	"function": "end",
+	"if":       "end",
+	"do":       "end",
+	"while":    "do",
+	"for":      "do",
+	"repeat":   "until",
+	"(":        ")",
+	"{":        "}",
+	"[":        "]",
+}
+
+type luaChecker struct {
+	src    []byte
+	pos    int
+	line   int
+	blocks []block
+}
+
+// CheckLua looks for syntax errors in the Lua source src.
+//
+// It is not a full parser: it checks that strings and comments are closed, that brackets match,
+// and that every block is closed by the right keyword, which catches most mistakes made while
+// editing. Expressions and statements are not checked.
+func CheckLua(src []byte) error {
+	c := &luaChecker{src: src, line: 1}
+
+	// A first line starting with # is skipped by the Lua interpreter
+	if len(src) > 0 && src[0] == '#' {
+		for c.pos < len(src) && src[c.pos] != '\n' {
+			c.pos++
+		}
+	}
+
+	for c.pos < len(src) {
+		if err := c.next(); err != nil {
+			return err
+		}
+	}
+
+	if n := len(c.blocks); n > 0 {
+		top := c.blocks[n-1]
+		return c.fail("'%s' expected (to close '%s' at line %d) near <eof>", top.closer(), top.kind, top.line)
+	}
+	return nil
+}
+
+func (c *luaChecker) fail(format string, args ...interface{}) error {
+	return &LuaSyntaxError{c.line, fmt.Sprintf(format, args...)}
+}
+
+func (c *luaChecker) peek(offset int) byte {
+	if c.pos+offset < len(c.src) {
+		return c.src[c.pos+offset]
+	}
+	return 0
+}
+
+func isNameStart(b byte) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f' || b == '\v'
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// next checks the token at c.pos and moves past it.
+func (c *luaChecker) next() error {
+	b := c.src[c.pos]
+	switch {
+	case b == '\n':
+		c.line++
+		c.pos++
+
+	case b == '-' && c.peek(1) == '-':
+		c.pos += 2
+		if level, ok := c.longBracket(); ok {
+			return c.skipLong(level, "comment")
+		}
+		for c.pos < len(c.src) && c.src[c.pos] != '\n' {
+			c.pos++
+		}
+
+	case b == '[':
+		if level, ok := c.longBracket(); ok {
+			return c.skipLong(level, "string")
+		}
+		c.open("[")
+		c.pos++
+
+	case b == '"' || b == '\'':
+		return c.skipString(b)
+
+	case isNameStart(b):
+		start := c.pos
+		for c.pos < len(c.src) && (isNameStart(c.src[c.pos]) || isDigit(c.src[c.pos])) {
+			c.pos++
+		}
+		return c.keyword(string(c.src[start:c.pos]))
+
+	case isDigit(b) || (b == '.' && isDigit(c.peek(1))):
+		c.skipNumber()
+
+	case b == '(' || b == '{':
+		c.open(string(b))
+		c.pos++
+
+	case b == ')' || b == '}' || b == ']':
+		if err := c.close(string(b)); err != nil {
+			return err
+		}
+		c.pos++
+
+	default:
+		c.pos++
+	}
+	return nil
+}
+
+// longBracket checks for the start of a long string or comment, such as [[ or [==[, at c.pos.
+// If there is one, it moves past it and returns its level, the number of equals signs.
+func (c *luaChecker) longBracket() (int, bool) {
+	if c.peek(0) != '[' {
+		return 0, false
+	}
+	level := 0
+	for c.peek(1+level) == '=' {
+		level++
+	}
+	if c.peek(1+level) != '[' {
+		return 0, false
+	}
+	c.pos += level + 2
+	return level, true
+}
+
+// skipLong moves past the end of a long string or comment of the given level.
+func (c *luaChecker) skipLong(level int, what string) error {
+	start := c.line
+	for c.pos < len(c.src) {
+		b := c.src[c.pos]
+		c.pos++
+		if b == '\n' {
+			c.line++
+		} else if b == ']' {
+			n := 0
+			for c.peek(n) == '=' {
+				n++
+			}
+			if n == level && c.peek(n) == ']' {
+				c.pos += n + 1
+				return nil
+			}
+		}
+	}
+	return c.fail("unfinished long %s (starting at line %d) near <eof>", what, start)
+}
+
+// skipString moves past a quoted string.
+func (c *luaChecker) skipString(quote byte) error {
+	c.pos++
+	for c.pos < len(c.src) {
+		switch c.src[c.pos] {
+		case quote:
+			c.pos++
+			return nil
+		case '\n':
+			return c.fail("unfinished string")
+		case '\\':
+			c.pos++
+			switch c.peek(0) {
+			case '\n':
+				c.line++
+			case 'z':
+				// \z skips the whitespace that follows it, including line breaks
+				for isSpace(c.peek(1)) {
+					c.pos++
+					if c.src[c.pos] == '\n' {
+						c.line++
+					}
+				}
+			}
+		}
+		c.pos++
+	}
+	return c.fail("unfinished string near <eof>")
+}
+
+// skipNumber moves past a numeric constant, such as 42, 0x1p-4 or 1e+10.
+func (c *luaChecker) skipNumber() {
+	exponent := "Ee"
+	if c.peek(0) == '0' && (c.peek(1) == 'x' || c.peek(1) == 'X') {
+		exponent = "Pp"
+		c.pos += 2
+	}
+	for c.pos < len(c.src) {
+		b := c.src[c.pos]
+		if (b == exponent[0] || b == exponent[1]) && (c.peek(1) == '+' || c.peek(1) == '-') {
+			c.pos += 2
+		} else if isDigit(b) || isNameStart(b) || b == '.' {
+			c.pos++
+		} else {
+			return
+		}
+	}
+}
+
+func (c *luaChecker) open(kind string) {
+	c.blocks = append(c.blocks, block{kind: kind, line: c.line})
+}
+
+func (c *luaChecker) top() (block, bool) {
+	if len(c.blocks) == 0 {
+		return block{}, false
+	}
+	return c.blocks[len(c.blocks)-1], true
+}
+
+// close ends the innermost block, which must be one that token closes.
+func (c *luaChecker) close(token string) error {
+	top, ok := c.top()
+	if !ok {
+		return c.fail("unexpected '%s'", token)
+	}
+	if top.closer() != token {
+		return c.fail("'%s' expected (to close '%s' at line %d) near '%s'", top.closer(), top.kind, top.line, token)
+	}
+	c.blocks = c.blocks[:len(c.blocks)-1]
+	return nil
+}
+
+// keyword handles a name, which is only of interest if it starts or ends a block.
+func (c *luaChecker) keyword(name string) error {
+	switch name {
+	case "function", "if", "while", "for", "repeat":
+		c.open(name)
+
+	case "do":
+		// The do of a loop closes the loop's header and opens its body
+		if top, ok := c.top(); ok && (top.kind == "while" || top.kind == "for") && !top.body {
+			c.blocks[len(c.blocks)-1].body = true
+		} else {
+			c.open("do")
+		}
+
+	case "then", "elseif", "else":
+		if top, ok := c.top(); !ok || top.kind != "if" {
+			return c.fail("unexpected '%s'", name)
+		}
+
+	case "end", "until":
+		return c.close(name)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package mod
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckLua(t *testing.T) {
+	valid := []string{
+		"",
+		"#!/usr/bin/lua\nprint('hi')",
+		"local t = { a = 1, [\"end\"] = 2, b = { 3 } }",
+		"function f(a, ...) if a then return 1 elseif b then return 2 else return 3 end end",
+		"for i = 1, 10 do while true do break end end",
+		"repeat local x = function() end until x",
+		"do local s = [[\nfunction\n]] .. [==[ ]] ]==] end",
+		"--[[ if\nwhile ]] x = 1 -- end\n--[=[ ]] ]=]",
+		"local s = 'it\\'s' .. \"a \\\"quote\\\"\" .. 'line\\\ncontinued'",
+		"x = 0x1p-4 + 1e+10 + 3.5e-2 + .5 + 0xff",
+		"t[i] = f(g(1), {2})",
+		"goto continue ::continue::",
+		"local s = 'skips \\z\n      whitespace' .. \"and \\z  \\z\r\n  more\"",
+	}
+	for _, src := range valid {
+		if err := CheckLua([]byte(src)); err != nil {
+			t.Fatalf("Got %v for %q, expected no error", err, src)
+		}
+	}
+
+	invalid := []struct {
+		src  string
+		line int
+		msg  string
+	}{
+		{"function f()\n  return 1\n", 3, "'end' expected (to close 'function' at line 1) near <eof>"},
+		{"if x then\nend\nend", 3, "unexpected 'end'"},
+		{"for i = 1, 2\nend", 2, "'do' expected (to close 'for' at line 1) near 'end'"},
+		{"while x do\n  f(\nend", 3, "')' expected (to close '(' at line 2) near 'end'"},
+		{"x = 'abc\ny = 1", 1, "unfinished string"},
+		{"x = [[\nabc", 2, "unfinished long string (starting at line 1) near <eof>"},
+		{"--[==[\n]]", 2, "unfinished long comment (starting at line 1) near <eof>"},
+		{"t = { 1, 2 )", 1, "'}' expected (to close '{' at line 1) near ')'"},
+		{"else", 1, "unexpected 'else'"},
+		{"x = 'a\\z\n\n  b'\nif x then", 4, "'end' expected (to close 'if' at line 4) near <eof>"},
+		{"repeat\nend", 2, "'until' expected (to close 'repeat' at line 1) near 'end'"},
+	}
+	for _, test := range invalid {
+		err := CheckLua([]byte(test.src))
+		syntaxErr := &LuaSyntaxError{}
+		if !errors.As(err, &syntaxErr) {
+			t.Fatalf("Got %v for %q, expected a syntax error", err, test.src)
+		}
+		if syntaxErr.Line != test.line || syntaxErr.Msg != test.msg {
+			t.Fatalf("Got %d: %s for %q, expected %d: %s", syntaxErr.Line, syntaxErr.Msg, test.src, test.line, test.msg)
+		}
+	}
+}
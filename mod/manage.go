@@ -0,0 +1,204 @@
+package mod
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Info describes a mod found in a game directory.
+type Info struct {
+	Name    string
+	Dir     string
+	Enabled bool
+	// HasMain reports whether the mod has a main.lua, without which the game cannot load it.
+	HasMain bool
+	// Manifest is the mod's manifest, if it has one.
+	Manifest Manifest
+	// Installed is the install record of the mod, or nil if it was not installed by jhmod.
+	Installed *Installed
+}
+
+// List returns the enabled and disabled mods in gameDir, sorted by name.
+func List(gameDir string) ([]Info, error) {
+	record, err := LoadRecord(gameDir)
+	if err != nil {
+		return nil, err
+	}
+
+	mods := []Info{}
+	for _, parent := range []string{filepath.Join(gameDir, ModsDir), filepath.Join(gameDir, StateDir, DisabledDir)} {
+		entries, err := os.ReadDir(parent)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, e := range entries {
+			if !e.IsDir() {
+				continue
+			}
+
+			dir := filepath.Join(parent, e.Name())
+			info := Info{
+				Name:      e.Name(),
+				Dir:       dir,
+				Enabled:   parent == filepath.Join(gameDir, ModsDir),
+				Installed: record.Find(e.Name()),
+			}
+			if _, err := os.Stat(filepath.Join(dir, MainFile)); err == nil {
+				info.HasMain = true
+			}
+			if m, err := ReadManifest(dir); err == nil {
+				info.Manifest = m
+			}
+			mods = append(mods, info)
+		}
+	}
+
+	sort.Slice(mods, func(i, j int) bool {
+		return mods[i].Name < mods[j].Name
+	})
+	return mods, nil
+}
+
+// SetEnabled enables or disables the mod called name in gameDir.
+// A disabled mod is moved out of the mods directory into the jhmod directory, so that the game does not load it.
+func SetEnabled(gameDir, name string, enabled bool) error {
+	dir, wasEnabled, err := Locate(gameDir, name)
+	if err != nil {
+		return err
+	}
+	if wasEnabled == enabled {
+		return nil
+	}
+
+	target := disabledDir(gameDir, name)
+	if enabled {
+		target = Dir(gameDir, name)
+
+		files, err := modFiles(dir)
+		if err != nil {
+			return err
+		}
+		conflicts, err := findConflicts(gameDir, name, files)
+		if err != nil {
+			return err
+		}
+		if len(conflicts) > 0 {
+			return conflictError(conflicts)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	return os.Rename(dir, target)
+}
+
+// Conflict is a file that is in more than one mod.
+type Conflict struct {
+	Path string
+	Mods []string
+}
+
+// shared reports whether p is a file that every mod has, and so cannot conflict.
+func shared(p string) bool {
+	return p == MainFile || p == ManifestFile || isReference(p)
+}
+
+// modFiles returns the paths of the files in the mod in dir, relative to dir and separated by slashes.
+func modFiles(dir string) ([]string, error) {
+	files := []string{}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		// Hidden files such as .git are left out, as they are by OpenPackage
+		if rel != "." && d.IsDir() && (isReference(rel) || hidden(rel)) {
+			return filepath.SkipDir
+		}
+		if d.Type().IsRegular() && !hidden(rel) {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// Conflicts returns the files that are in more than one enabled mod in gameDir, sorted by path.
+// Every mod has its own main.lua and manifest, so those are not counted.
+func Conflicts(gameDir string) ([]Conflict, error) {
+	mods, err := List(gameDir)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := map[string][]string{}
+	for _, m := range mods {
+		if !m.Enabled {
+			continue
+		}
+		files, err := modFiles(m.Dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if !shared(f) {
+				owners[f] = append(owners[f], m.Name)
+			}
+		}
+	}
+
+	conflicts := []Conflict{}
+	for p, names := range owners {
+		if len(names) > 1 {
+			conflicts = append(conflicts, Conflict{p, names})
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Path < conflicts[j].Path
+	})
+	return conflicts, nil
+}
+
+// findConflicts returns the files that the mod called name, with the given files, would have in common
+// with the other enabled mods in gameDir.
+func findConflicts(gameDir, name string, files []string) ([]Conflict, error) {
+	mods, err := List(gameDir)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := map[string][]string{}
+	for _, m := range mods {
+		if !m.Enabled || m.Name == name {
+			continue
+		}
+		theirs, err := modFiles(m.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Name, err)
+		}
+		for _, f := range theirs {
+			owners[f] = append(owners[f], m.Name)
+		}
+	}
+
+	conflicts := []Conflict{}
+	for _, f := range files {
+		if others := owners[f]; len(others) > 0 && !shared(f) {
+			conflicts = append(conflicts, Conflict{f, append([]string{name}, others...)})
+		}
+	}
+	return conflicts, nil
+}
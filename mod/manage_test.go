@@ -0,0 +1,68 @@
+package mod
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestListAndSetEnabled(t *testing.T) {
+	gameDir := t.TempDir()
+	writeDir(t, Dir(gameDir, "a"), map[string]string{"main.lua": "", "mod.json": `{"Name":"a","Version":"1.0"}`, "data/x.lua": ""})
+	writeDir(t, Dir(gameDir, "b"), map[string]string{"data/x.lua": ""})
+	writeDir(t, Dir(gameDir, "c"), map[string]string{"main.lua": "", "data/x.lua": "", "reference/y.lua": ""})
+
+	conflicts, err := Conflicts(gameDir)
+	if err != nil {
+		t.Fatalf("Conflicts failed: %v", err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Path != "data/x.lua" || strings.Join(conflicts[0].Mods, " ") != "a b c" {
+		t.Fatalf("Got %+v, expected data/x.lua in a, b and c", conflicts)
+	}
+
+	if err := SetEnabled(gameDir, "b", false); err != nil {
+		t.Fatalf("Disable failed: %v", err)
+	}
+	if err := SetEnabled(gameDir, "c", false); err != nil {
+		t.Fatalf("Disable failed: %v", err)
+	}
+	if err := SetEnabled(gameDir, "c", false); err != nil {
+		t.Fatalf("Disabling a disabled mod failed: %v", err)
+	}
+
+	mods, err := List(gameDir)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	summary := []string{}
+	for _, m := range mods {
+		summary = append(summary, m.Name+"="+map[bool]string{true: "on", false: "off"}[m.Enabled])
+	}
+	if got := strings.Join(summary, " "); got != "a=on b=off c=off" {
+		t.Fatalf("Got %s, expected a=on b=off c=off", got)
+	}
+	if !mods[0].HasMain || mods[0].Manifest.Version != "1.0" || mods[1].HasMain {
+		t.Fatalf("Got %+v, expected a to have main.lua and a manifest, and b to have neither", mods)
+	}
+
+	if conflicts, _ := Conflicts(gameDir); len(conflicts) != 0 {
+		t.Fatalf("Got %+v, expected no conflicts", conflicts)
+	}
+
+	// Enabling c again would conflict with a
+	if err := SetEnabled(gameDir, "c", true); err == nil {
+		t.Fatalf("Got no error enabling a conflicting mod")
+	}
+	if err := SetEnabled(gameDir, "a", false); err != nil {
+		t.Fatalf("Disable failed: %v", err)
+	}
+	if err := SetEnabled(gameDir, "c", true); err != nil {
+		t.Fatalf("Enable failed: %v", err)
+	}
+	if _, enabled, err := Locate(gameDir, "c"); err != nil || !enabled {
+		t.Fatalf("Got %v, expected c to be enabled", err)
+	}
+
+	if err := SetEnabled(gameDir, "missing", true); err == nil {
+		t.Fatalf("Got no error enabling a missing mod")
+	}
+}
@@ -0,0 +1,132 @@
+// Package mod creates, installs and manages Jupiter Hell mods.
+//
+// The game loads each mod from its own folder in the mods directory of the game directory, and
+// runs the main.lua in that folder when it starts. jhmod also keeps a manifest, mod.json, in the
+// folder with the mod's name, version and dependencies.
+//
+// Mods installed by jhmod are listed in an install record in the jhmod directory of the game
+// directory, so that they can be uninstalled without touching files that were added by hand.
+// Disabled mods are moved into the same directory, out of the game's reach.
+package mod
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// ModsDir is the directory within the game directory that holds mods.
+	ModsDir = "mods"
+	// MainFile is the file that the game runs for each mod.
+	MainFile = "main.lua"
+	// ManifestFile holds a mod's Manifest.
+	ManifestFile = "mod.json"
+	// ReferenceDir holds Lua files extracted from the game for reference. It is not part of the mod.
+	ReferenceDir = "reference"
+)
+
+// Manifest describes a mod.
+type Manifest struct {
+	Name         string
+	Version      string
+	Description  string   `json:",omitempty"`
+	Author       string   `json:",omitempty"`
+	GameVersion  string   `json:",omitempty"`
+	Dependencies []string `json:",omitempty"`
+}
+
+// ReadManifest reads the manifest of the mod in dir.
+func ReadManifest(dir string) (Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	m := Manifest{}
+	if err := unmarshalManifest(data, &m); err != nil {
+		return Manifest{}, err
+	}
+	return m, nil
+}
+
+func unmarshalManifest(data []byte, m *Manifest) error {
+	if err := json.Unmarshal(data, m); err != nil {
+		return fmt.Errorf("%s: %w", ManifestFile, err)
+	}
+	return nil
+}
+
+// WriteManifest writes m as the manifest of the mod in dir.
+func WriteManifest(dir string, m Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, ManifestFile), append(data, '\n'), 0644)
+}
+
+// ValidName returns an error if name cannot be used as the name of a mod's folder.
+func ValidName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("mod name is empty")
+	case name == "." || name == "..":
+		return fmt.Errorf("%q is not a valid mod name", name)
+	case strings.ContainsAny(name, `/\:*?"<>|`):
+		return fmt.Errorf("mod name %q contains a character that cannot be used in a folder name", name)
+	case strings.TrimSpace(name) != name:
+		return fmt.Errorf("mod name %q starts or ends with a space", name)
+	}
+	return nil
+}
+
+// Dir returns the folder of the mod called name in gameDir.
+func Dir(gameDir, name string) string {
+	return filepath.Join(gameDir, ModsDir, name)
+}
+
+// mainTemplate is the main.lua written by Init. %s is the mod's name.
+const mainTemplate = `-- %s
+--
+-- The game runs this file when it loads the mod.
+-- The files in reference/, if there are any, were extracted from the game
+-- by "jhmod mod init --reference" to show the tables that mods can change.
+-- They are not part of the mod and are not loaded by the game.
+`
+
+// Init creates the folder for a new mod called name in gameDir, with a main.lua and a manifest,
+// and returns the path of the folder. The mods directory is created if needed.
+// It is an error for the mod's folder to exist already.
+func Init(gameDir, name string) (string, error) {
+	if err := ValidName(name); err != nil {
+		return "", err
+	}
+
+	if info, err := os.Stat(gameDir); err != nil {
+		return "", err
+	} else if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", gameDir)
+	}
+
+	if err := os.MkdirAll(filepath.Join(gameDir, ModsDir), 0755); err != nil {
+		return "", err
+	}
+
+	dir := Dir(gameDir, name)
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return "", err
+	}
+
+	main := fmt.Sprintf(mainTemplate, name)
+	if err := os.WriteFile(filepath.Join(dir, MainFile), []byte(main), 0644); err != nil {
+		return "", err
+	}
+	if err := WriteManifest(dir, Manifest{Name: name, Version: "0.1.0"}); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
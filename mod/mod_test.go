@@ -0,0 +1,90 @@
+package mod
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidName(t *testing.T) {
+	tests := map[string]bool{
+		"coolestmod":  true,
+		"Cool Mod 2":  true,
+		"":            false,
+		".":           false,
+		"..":          false,
+		"a/b":         false,
+		`a\b`:         false,
+		"mod?":        false,
+		" padded ":    false,
+		"unicode-мод": true,
+	}
+	for name, valid := range tests {
+		if err := ValidName(name); (err == nil) != valid {
+			t.Fatalf("Got %v for %q, expected valid=%v", err, name, valid)
+		}
+	}
+}
+
+func TestInit(t *testing.T) {
+	gameDir := t.TempDir()
+
+	dir, err := Init(gameDir, "coolestmod")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if expected := filepath.Join(gameDir, "mods", "coolestmod"); dir != expected {
+		t.Fatalf("Got %s, expected %s", dir, expected)
+	}
+
+	main, err := os.ReadFile(filepath.Join(dir, MainFile))
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", MainFile, err)
+	}
+	if !strings.HasPrefix(string(main), "-- coolestmod\n") {
+		t.Fatalf("Got %q, expected main.lua to start with the mod name", main)
+	}
+
+	m, err := ReadManifest(dir)
+	if err != nil {
+		t.Fatalf("ReadManifest failed: %v", err)
+	}
+	if m.Name != "coolestmod" || m.Version == "" {
+		t.Fatalf("Got %+v, expected a manifest for coolestmod", m)
+	}
+
+	if _, err := Init(gameDir, "coolestmod"); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("Got %v, expected %v", err, os.ErrExist)
+	}
+	if _, err := Init(gameDir, "../escape"); err == nil {
+		t.Fatalf("Got no error for an invalid name")
+	}
+	if _, err := Init(filepath.Join(gameDir, "missing"), "mod"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Got %v, expected %v", err, os.ErrNotExist)
+	}
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	m := Manifest{Name: "a", Version: "1.2.3", GameVersion: "1.0", Dependencies: []string{"b", "c"}}
+	if err := WriteManifest(dir, m); err != nil {
+		t.Fatalf("WriteManifest failed: %v", err)
+	}
+
+	got, err := ReadManifest(dir)
+	if err != nil {
+		t.Fatalf("ReadManifest failed: %v", err)
+	}
+	if got.Name != m.Name || got.Version != m.Version || got.GameVersion != m.GameVersion || len(got.Dependencies) != 2 || got.Dependencies[1] != "c" {
+		t.Fatalf("Got %+v, expected %+v", got, m)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ManifestFile), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadManifest(dir); err == nil {
+		t.Fatalf("Got no error for an invalid manifest")
+	}
+}
@@ -0,0 +1,232 @@
+package mod
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sector-f/jhmod/luac"
+	"github.com/sector-f/jhmod/nvc"
+)
+
+// packTime is the modification time given to every file in a packed mod, so that packing the same
+// files always gives the same zip file. It is the earliest time that a zip file can hold.
+var packTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Package is a mod folder to be packed for distribution.
+type Package struct {
+	Dir      string
+	Manifest Manifest
+	// Files are the paths of the files to pack, relative to Dir, separated by slashes and sorted.
+	// The manifest, the reference directory and hidden files such as .git are left out.
+	Files []string
+
+	// irregular are files that cannot be packed, such as symbolic links
+	irregular []string
+}
+
+// hidden reports whether p, a slash separated path, is or is in a hidden file or folder.
+func hidden(p string) bool {
+	for _, part := range strings.Split(p, "/") {
+		if strings.HasPrefix(part, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenPackage reads the mod in dir. If it has no manifest, the manifest is named after dir.
+func OpenPackage(dir string) (*Package, error) {
+	m, err := ReadManifest(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		m = Manifest{Name: filepath.Base(abs)}
+	} else if err != nil {
+		return nil, err
+	}
+
+	p := &Package{Dir: dir, Manifest: m, Files: []string{}, irregular: []string{}}
+	err = filepath.WalkDir(dir, func(fp string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, fp)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		switch {
+		case rel == ".":
+			return nil
+		case d.IsDir() && (isReference(rel) || hidden(rel)):
+			return filepath.SkipDir
+		case d.IsDir() || rel == ManifestFile || hidden(rel):
+			return nil
+		case d.Type().IsRegular():
+			p.Files = append(p.Files, rel)
+		default:
+			p.irregular = append(p.irregular, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(p.Files)
+	return p, nil
+}
+
+// Validate returns every problem that would stop the mod from being packed: a manifest without a
+// valid name or a version, a missing main.lua, files that are not regular files, or Lua files with
+// syntax errors. Lua bytecode is not checked.
+func (p *Package) Validate() []error {
+	problems := []error{}
+	if err := ValidName(p.Manifest.Name); err != nil {
+		problems = append(problems, fmt.Errorf("%s: %w", ManifestFile, err))
+	}
+	if p.Manifest.Version == "" {
+		problems = append(problems, fmt.Errorf("%s: mod has no version", ManifestFile))
+	}
+	for _, dep := range p.Manifest.Dependencies {
+		if err := ValidName(dep); err != nil {
+			problems = append(problems, fmt.Errorf("%s: dependency: %w", ManifestFile, err))
+		}
+	}
+
+	hasMain := false
+	for _, f := range p.Files {
+		if f == MainFile {
+			hasMain = true
+		}
+		if err := checkPath(f); err != nil {
+			problems = append(problems, err)
+			continue
+		}
+		if path.Ext(f) != ".lua" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(p.Dir, filepath.FromSlash(f)))
+		if err != nil {
+			problems = append(problems, err)
+			continue
+		}
+		if luac.IsBytecode(data) {
+			continue
+		}
+		if err := CheckLua(data); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", f, err))
+		}
+	}
+	if !hasMain {
+		problems = append(problems, fmt.Errorf("mod has no %s", MainFile))
+	}
+
+	for _, f := range p.irregular {
+		problems = append(problems, fmt.Errorf("%s: not a regular file, so it cannot be packed", f))
+	}
+	return problems
+}
+
+// WriteZip writes the mod to w as a zip file, with its files in a folder named after the mod and
+// its manifest as mod.json. The same files and manifest always give the same zip file.
+func (p *Package) WriteZip(w io.Writer) error {
+	manifest, err := json.MarshalIndent(p.Manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	manifest = append(manifest, '\n')
+
+	entries := append([]string{ManifestFile}, p.Files...)
+	sort.Strings(entries)
+
+	z := zip.NewWriter(w)
+	for _, name := range entries {
+		header := &zip.FileHeader{
+			Name:     p.Manifest.Name + "/" + name,
+			Method:   zip.Deflate,
+			Modified: packTime,
+		}
+		header.SetMode(0644)
+
+		fw, err := z.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		if name == ManifestFile {
+			_, err = fw.Write(manifest)
+		} else {
+			err = p.copyTo(fw, name)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return z.Close()
+}
+
+func (p *Package) copyTo(w io.Writer, name string) error {
+	f, err := os.Open(filepath.Join(p.Dir, filepath.FromSlash(name)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// Assets returns the files that WriteNVC stores, which are every file except main.lua.
+func (p *Package) Assets() []string {
+	assets := []string{}
+	for _, f := range p.Files {
+		if f != MainFile {
+			assets = append(assets, f)
+		}
+	}
+	return assets
+}
+
+// WriteNVC writes the mod's assets to w as an archive, each under its path relative to the mod's folder.
+// If level is at least 0, the files are compressed at that level.
+func (p *Package) WriteNVC(w io.WriteSeeker, level int) error {
+	assets := p.Assets()
+	writer, err := nvc.NewWriter(w, uint32(len(assets)))
+	if err != nil {
+		return err
+	}
+
+	for _, name := range assets {
+		data, err := os.ReadFile(filepath.Join(p.Dir, filepath.FromSlash(name)))
+		if err != nil {
+			return err
+		}
+
+		hash := nvc.String2Hash(name)
+		if level >= 0 {
+			_, err = writer.CreateCompressed(bytes.NewReader(data), hash, level)
+		} else {
+			_, err = writer.Create(bytes.NewReader(data), hash)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return writer.Finalize()
+}
@@ -0,0 +1,148 @@
+package mod
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sector-f/jhmod/nvc"
+)
+
+func TestPack(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "coolestmod")
+	writeDir(t, dir, map[string]string{
+		"main.lua":         "require 'lib.util'",
+		"lib/util.lua":     "function util() end",
+		"data/sprite.png":  "png",
+		"reference/x.lua":  "function broken(",
+		".git/config":      "",
+		"notes/.draft.lua": "function broken(",
+		"mod.json":         `{"Name":"coolestmod","Version":"1.0","Dependencies":["other"]}`,
+	})
+
+	p, err := OpenPackage(dir)
+	if err != nil {
+		t.Fatalf("OpenPackage failed: %v", err)
+	}
+	if files := strings.Join(p.Files, " "); files != "data/sprite.png lib/util.lua main.lua" {
+		t.Fatalf("Got %s, expected data/sprite.png lib/util.lua main.lua", files)
+	}
+	if problems := p.Validate(); len(problems) != 0 {
+		t.Fatalf("Got %v, expected no problems", problems)
+	}
+
+	first, second := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := p.WriteZip(first); err != nil {
+		t.Fatalf("WriteZip failed: %v", err)
+	}
+
+	// Touching the files must not change the zip
+	if err := os.Chtimes(filepath.Join(dir, "main.lua"), packTime.AddDate(30, 0, 0), packTime.AddDate(30, 0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.WriteZip(second); err != nil {
+		t.Fatalf("WriteZip failed: %v", err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Fatalf("Got different zip files from the same mod")
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(first.Bytes()), int64(first.Len()))
+	if err != nil {
+		t.Fatalf("Failed to read zip: %v", err)
+	}
+	names := []string{}
+	for _, f := range z.File {
+		names = append(names, f.Name)
+	}
+	expected := "coolestmod/data/sprite.png coolestmod/lib/util.lua coolestmod/main.lua coolestmod/mod.json"
+	if got := strings.Join(names, " "); got != expected {
+		t.Fatalf("Got %s, expected %s", got, expected)
+	}
+
+	// The packed mod can be installed
+	zipPath := filepath.Join(t.TempDir(), "coolestmod.zip")
+	if err := os.WriteFile(zipPath, first.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	installed, err := install(t, t.TempDir(), zipPath, InstallOptions{})
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if installed.Name != "coolestmod" || installed.Version != "1.0" {
+		t.Fatalf("Got %+v, expected coolestmod 1.0", installed)
+	}
+}
+
+func TestPackValidate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "broken")
+	writeDir(t, dir, map[string]string{
+		"init.lua": "function f()",
+	})
+	if err := os.Symlink("/etc/passwd", filepath.Join(dir, "link")); err != nil {
+		t.Skipf("Cannot create symbolic links: %v", err)
+	}
+
+	p, err := OpenPackage(dir)
+	if err != nil {
+		t.Fatalf("OpenPackage failed: %v", err)
+	}
+	if p.Manifest.Name != "broken" {
+		t.Fatalf("Got %s, expected the mod to be named after its folder", p.Manifest.Name)
+	}
+
+	problems := []string{}
+	for _, err := range p.Validate() {
+		problems = append(problems, err.Error())
+	}
+	expected := []string{
+		"mod.json: mod has no version",
+		"init.lua: line 1: 'end' expected (to close 'function' at line 1) near <eof>",
+		"mod has no main.lua",
+		"link: not a regular file, so it cannot be packed",
+	}
+	if strings.Join(problems, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Got %q, expected %q", problems, expected)
+	}
+}
+
+func TestPackNVC(t *testing.T) {
+	dir := t.TempDir()
+	writeDir(t, dir, map[string]string{
+		"main.lua":        "",
+		"data/lua/a.lua":  "a = 1",
+		"data/sprite.png": "png",
+	})
+	p, err := OpenPackage(dir)
+	if err != nil {
+		t.Fatalf("OpenPackage failed: %v", err)
+	}
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "mod.nvc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := p.WriteNVC(f, 9); err != nil {
+		t.Fatalf("WriteNVC failed: %v", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := nvc.Parse(f)
+	if err != nil {
+		t.Fatalf("Failed to parse archive: %v", err)
+	}
+	if len(archive.Entries) != 2 {
+		t.Fatalf("Got %d entries, expected 2", len(archive.Entries))
+	}
+	data, err := archive.File(nvc.String2Hash("data/lua/a.lua"))
+	if err != nil || string(data) != "a = 1" {
+		t.Fatalf("Got %q, %v, expected %q", data, err, "a = 1")
+	}
+}
@@ -0,0 +1,54 @@
+package mod
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/sector-f/jhmod/nvc"
+)
+
+// ExtractReference writes each Lua file in archive that is named in pathlist to dir, keeping its
+// path within the archive. The files are made read-only, as a reminder that changing them has no
+// effect on the game. Paths that are not in the archive are skipped.
+// The number of files written is returned.
+func ExtractReference(archive nvc.Archive, pathlist []string, dir string) (int, error) {
+	count := 0
+	seen := map[string]bool{}
+	for _, p := range pathlist {
+		if !strings.HasSuffix(p, ".lua") || seen[p] {
+			continue
+		}
+		seen[p] = true
+
+		clean := path.Clean(p)
+		if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+			return count, fmt.Errorf("pathlist entry %q is outside the archive", p)
+		}
+
+		data, err := archive.File(nvc.String2Hash(p))
+		if errors.Is(err, nvc.ErrHashNotFound) {
+			continue
+		} else if err != nil {
+			return count, fmt.Errorf("%s: %w", p, err)
+		}
+
+		outPath := filepath.Join(dir, filepath.FromSlash(clean))
+		if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+			return count, err
+		}
+
+		// An earlier extraction leaves read-only files, which cannot be written over
+		if err := os.Remove(outPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return count, err
+		}
+		if err := os.WriteFile(outPath, data, 0444); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
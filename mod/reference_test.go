@@ -0,0 +1,87 @@
+package mod
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sector-f/jhmod/nvc"
+)
+
+// makeArchive writes an archive holding files, keyed by path, and returns it parsed.
+func makeArchive(t *testing.T, files map[string]string) nvc.Archive {
+	f, err := os.Create(filepath.Join(t.TempDir(), "core.nvc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+
+	w, err := nvc.NewWriter(f, uint32(len(files)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for p, contents := range files {
+		if _, err := w.Create(bytes.NewBufferString(contents), nvc.String2Hash(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Finalize(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := nvc.Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return archive
+}
+
+func TestExtractReference(t *testing.T) {
+	archive := makeArchive(t, map[string]string{
+		"data/lua/jh/main.lua":       "-- main",
+		"data/lua/jh/data/items.lua": "items = {}",
+		"data/lang/en.csv":           "key,text",
+	})
+	pathlist := []string{
+		"data/lua/jh/main.lua",
+		"data/lua/jh/data/items.lua",
+		"data/lua/jh/data/items.lua",
+		"data/lua/jh/missing.lua",
+		"data/lang/en.csv",
+	}
+
+	dir := t.TempDir()
+	for i := 0; i < 2; i++ { // The second time writes over the read-only files from the first
+		count, err := ExtractReference(archive, pathlist, dir)
+		if err != nil {
+			t.Fatalf("ExtractReference failed: %v", err)
+		}
+		if count != 2 {
+			t.Fatalf("Got %d files, expected 2", count)
+		}
+	}
+
+	path := filepath.Join(dir, "data", "lua", "jh", "data", "items.lua")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read extracted file: %v", err)
+	}
+	if string(data) != "items = {}" {
+		t.Fatalf("Got %q, expected %q", data, "items = {}")
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm()&0222 != 0 {
+		t.Fatalf("Got mode %v, expected a read-only file", info.Mode())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "data", "lang", "en.csv")); err == nil {
+		t.Fatalf("Got a file that is not Lua")
+	}
+
+	if _, err := ExtractReference(archive, []string{"../../etc/passwd.lua"}, dir); err == nil {
+		t.Fatalf("Got no error for a path outside the archive")
+	}
+}
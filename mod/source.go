@@ -0,0 +1,220 @@
+package mod
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Source is a mod to be installed, read from a folder or a zip file.
+type Source struct {
+	// Name is the name of the mod's folder once it is installed.
+	Name string
+	// Manifest is the mod's manifest, if it has one.
+	Manifest Manifest
+	// Files are the paths of the files in the mod, relative to its folder and separated by slashes.
+	// The reference directory and hidden files such as .git are left out, as they are when a mod is packed.
+	Files []string
+
+	path  string
+	open  func(name string) (io.ReadCloser, error)
+	close func() error
+}
+
+// Open returns the contents of the file called name in the mod.
+func (s *Source) Open(name string) (io.ReadCloser, error) {
+	return s.open(name)
+}
+
+// Close releases the zip file that the mod was read from, if there is one.
+func (s *Source) Close() error {
+	if s.close == nil {
+		return nil
+	}
+	return s.close()
+}
+
+// checkPath returns an error if p, a slash separated path within a mod, is absolute or leaves the mod's folder.
+// Backslashes and colons are refused too: on Windows they would be read as separators or a drive name,
+// and could lead out of the mod's folder.
+func checkPath(p string) error {
+	clean := path.Clean(p)
+	if path.IsAbs(clean) || strings.ContainsAny(p, `\:`) || filepath.VolumeName(p) != "" ||
+		clean == ".." || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("%q is outside the mod's folder", p)
+	}
+	return nil
+}
+
+// localPath returns the path on disk of name, a slash separated path within the mod folder dir.
+// An error is returned if the result would not be inside dir.
+func localPath(dir, name string) (string, error) {
+	if err := checkPath(name); err != nil {
+		return "", err
+	}
+
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	rel, err := filepath.Rel(dir, p)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%q is outside the mod's folder", name)
+	}
+	return p, nil
+}
+
+// isReference reports whether p is in the reference directory of a mod.
+func isReference(p string) bool {
+	return p == ReferenceDir || strings.HasPrefix(p, ReferenceDir+"/")
+}
+
+// OpenSource reads the mod in the folder or zip file at p.
+//
+// A zip file may hold the mod's files at its top level, or in a single folder.
+// The mod is named after its manifest if it has one, and otherwise after that folder or the zip file or folder at p.
+func OpenSource(p string) (*Source, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var s *Source
+	if info.IsDir() {
+		s, err = openDir(p)
+	} else {
+		s, err = openZip(p)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if s.path, err = filepath.Abs(p); err != nil {
+		s.Close()
+		return nil, err
+	}
+	if s.Name == "" {
+		s.Name = strings.TrimSuffix(filepath.Base(p), filepath.Ext(p))
+	}
+	if err := s.readManifest(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	sort.Strings(s.Files)
+
+	if !s.has(MainFile) {
+		s.Close()
+		return nil, fmt.Errorf("%s: mod has no %s", p, MainFile)
+	}
+	return s, nil
+}
+
+func (s *Source) has(name string) bool {
+	for _, f := range s.Files {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Source) readManifest() error {
+	if !s.has(ManifestFile) {
+		return nil
+	}
+
+	r, err := s.open(ManifestFile)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if err := unmarshalManifest(data, &s.Manifest); err != nil {
+		return err
+	}
+	if s.Manifest.Name != "" {
+		s.Name = s.Manifest.Name
+	}
+	return nil
+}
+
+func openDir(dir string) (*Source, error) {
+	files, err := modFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Source{
+		Files: files,
+		open: func(name string) (io.ReadCloser, error) {
+			p, err := localPath(dir, name)
+			if err != nil {
+				return nil, err
+			}
+			return os.Open(p)
+		},
+	}, nil
+}
+
+func openZip(p string) (*Source, error) {
+	z, err := zip.OpenReader(p)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]*zip.File{}
+	for _, f := range z.File {
+		if err := checkPath(f.Name); err != nil {
+			z.Close()
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		if name := path.Clean(f.Name); f.Mode().IsRegular() && !hidden(name) {
+			files[name] = f
+		}
+	}
+
+	// Mods are often zipped with their folder
+	prefix := ""
+	if _, ok := files[MainFile]; !ok {
+		for name := range files {
+			dir, rest, ok := strings.Cut(name, "/")
+			if !ok || rest != MainFile {
+				continue
+			}
+			if prefix != "" {
+				z.Close()
+				return nil, fmt.Errorf("%s: holds more than one mod", p)
+			}
+			prefix = dir + "/"
+		}
+	}
+
+	s := &Source{
+		Name:  strings.TrimSuffix(prefix, "/"),
+		close: z.Close,
+		open: func(name string) (io.ReadCloser, error) {
+			f, ok := files[prefix+name]
+			if !ok {
+				return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+			}
+			return f.Open()
+		},
+	}
+	for name := range files {
+		if !strings.HasPrefix(name, prefix) {
+			z.Close()
+			return nil, fmt.Errorf("%s: %s is outside the mod's folder %s", p, name, prefix)
+		}
+		if rel := strings.TrimPrefix(name, prefix); !isReference(rel) {
+			s.Files = append(s.Files, rel)
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,115 @@
+package mod
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeZip writes a zip file holding files, keyed by path, and returns its path.
+func writeZip(t *testing.T, name string, files map[string]string) string {
+	p := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	z := zip.NewWriter(f)
+	for name, contents := range files {
+		w, err := z.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// writeDir writes files, keyed by slash separated path, into dir.
+func writeDir(t *testing.T, dir string, files map[string]string) {
+	for name, contents := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestOpenSource(t *testing.T) {
+	modDir := filepath.Join(t.TempDir(), "dirmod")
+	writeDir(t, modDir, map[string]string{
+		"main.lua":             "-- main",
+		"lib/util.lua":         "-- util",
+		"reference/data/x.lua": "-- reference",
+		".git/HEAD":            "ref: refs/heads/main",
+		".gitignore":           "*.zip",
+	})
+
+	tests := []struct {
+		path  string
+		name  string
+		files string
+	}{
+		{modDir, "dirmod", "lib/util.lua main.lua"},
+		{writeZip(t, "flat.zip", map[string]string{"main.lua": "", "a.lua": "", ".DS_Store": ""}), "flat", "a.lua main.lua"},
+		{writeZip(t, "wrapped.zip", map[string]string{"inner/main.lua": "", "inner/b/c.lua": ""}), "inner", "b/c.lua main.lua"},
+		{writeZip(t, "named.zip", map[string]string{"main.lua": "", "mod.json": `{"Name":"fromManifest","Version":"2.0"}`}), "fromManifest", "main.lua mod.json"},
+	}
+	for _, test := range tests {
+		src, err := OpenSource(test.path)
+		if err != nil {
+			t.Fatalf("OpenSource(%s) failed: %v", test.path, err)
+		}
+		src.Close()
+
+		if src.Name != test.name {
+			t.Fatalf("Got %s, expected %s", src.Name, test.name)
+		}
+		if files := strings.Join(src.Files, " "); files != test.files {
+			t.Fatalf("Got %s, expected %s", files, test.files)
+		}
+	}
+}
+
+func TestOpenSourceErrors(t *testing.T) {
+	tests := map[string]map[string]string{
+		"nomain.zip":  {"other.lua": ""},
+		"escape.zip":  {"main.lua": "", "../evil.lua": ""},
+		"abs.zip":     {"main.lua": "", "/etc/evil.lua": ""},
+		"two.zip":     {"a/main.lua": "", "b/main.lua": ""},
+		"outside.zip": {"a/main.lua": "", "stray.lua": ""},
+		"slash.zip":   {"main.lua": "", `x/..\..\..\evil.dll`: ""},
+		"parent.zip":  {"main.lua": "", `..\evil`: ""},
+		"drive.zip":   {"main.lua": "", "C:evil.dll": ""},
+	}
+	for name, files := range tests {
+		if src, err := OpenSource(writeZip(t, name, files)); err == nil {
+			src.Close()
+			t.Fatalf("Got no error for %s", name)
+		}
+	}
+}
+
+func TestLocalPath(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mod")
+
+	if p, err := localPath(dir, "lib/util.lua"); err != nil || p != filepath.Join(dir, "lib", "util.lua") {
+		t.Fatalf("Got %s and %v, expected %s", p, err, filepath.Join(dir, "lib", "util.lua"))
+	}
+	for _, name := range []string{"..", "../evil.lua", "lib/../../evil.lua", `..\evil`, `x/..\..\evil.dll`, "/etc/evil", "C:evil", "."} {
+		if p, err := localPath(dir, name); err == nil {
+			t.Fatalf("Got %s for %q, expected an error", p, name)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Package nmd inspects Jupiter Hell model files.
+//
+// Model files begin with the magic bytes "nmf1" and are extracted with a .nmd extension.
+// Beyond the magic bytes, the layout of the format (meshes, materials, bones) has not been
+// reverse-engineered yet, so this package does not attempt to decode it. Instead it provides
+// the views that are useful while working the format out: the words following the magic bytes,
+// and the printable strings embedded in the file, which include names of materials, bones and textures.
+package nmd
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// Magic is the magic bytes at the start of a model file.
+const Magic = "nmf1"
+
+// ErrNoMagicFound is returned when data does not start with Magic.
+var ErrNoMagicFound error = errors.New("nmd magic bytes not found")
+
+// File is a model file.
+type File struct {
+	Data []byte // Contents of the file, including the magic bytes
+}
+
+// Parse reads a model file from r.
+func Parse(r io.Reader) (File, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return File{}, err
+	}
+
+	if len(data) < len(Magic) || string(data[:len(Magic)]) != Magic {
+		return File{}, ErrNoMagicFound
+	}
+
+	return File{data}, nil
+}
+
+// Word is a 32-bit little endian value in a model file.
+type Word struct {
+	Offset int
+	Value  uint32
+}
+
+// Float returns w interpreted as a 32-bit float.
+func (w Word) Float() float32 {
+	return math.Float32frombits(w.Value)
+}
+
+// Header returns the first count words after the magic bytes, or as many as the file contains.
+func (f File) Header(count int) []Word {
+	words := []Word{}
+	for off := len(Magic); off+4 <= len(f.Data) && len(words) < count; off += 4 {
+		words = append(words, Word{off, binary.LittleEndian.Uint32(f.Data[off:])})
+	}
+	return words
+}
+
+// String is a run of printable ASCII in a model file.
+type String struct {
+	Offset int
+	Value  string
+}
+
+// Strings returns every run of at least minLen printable ASCII characters in f.
+func (f File) Strings(minLen int) []String {
+	found := []String{}
+	start := -1
+
+	for i := 0; i <= len(f.Data); i++ {
+		if i < len(f.Data) && f.Data[i] >= 0x20 && f.Data[i] < 0x7f {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+
+		if start >= 0 && i-start >= minLen {
+			found = append(found, String{start, string(f.Data[start:i])})
+		}
+		start = -1
+	}
+
+	return found
+}
@@ -0,0 +1,37 @@
+package nmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	data := []byte("nmf1\x02\x00\x00\x00\x00\x00\x80\xbfmat_floor\x00\x00ab\x00bone_root")
+
+	f, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := f.Header(2)
+	if len(header) != 2 || header[0].Value != 2 || header[1].Float() != -1 {
+		t.Fatalf("Got header %v, expected [2 -1.0]", header)
+	}
+
+	strings := f.Strings(4)
+	expected := []String{{0, "nmf1"}, {12, "mat_floor"}, {26, "bone_root"}}
+	if len(strings) != len(expected) {
+		t.Fatalf("Got %v, expected %v", strings, expected)
+	}
+	for i := range expected {
+		if strings[i] != expected[i] {
+			t.Fatalf("Got %v, expected %v", strings[i], expected[i])
+		}
+	}
+}
+
+func TestParseNoMagic(t *testing.T) {
+	if _, err := Parse(bytes.NewReader([]byte("OggS"))); err != ErrNoMagicFound {
+		t.Fatalf("Got %v, expected %v", err, ErrNoMagicFound)
+	}
+}
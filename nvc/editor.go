@@ -0,0 +1,293 @@
+package nvc
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Editor modifies an existing archive on disk.
+//
+// Changes made with Add, Replace and Remove are kept in memory until Commit is called,
+// at which point a new archive is written next to the original and renamed over it.
+// Members that were not changed are copied without being extracted or recompressed.
+type Editor struct {
+	// Compress controls whether members added with Add are zlib compressed.
+	// Replaced members keep the compression of the member they replace.
+	Compress bool
+	// Level is the zlib compression level used for compressed members.
+	Level int
+	// Alignment is passed on to the Writer used by Commit.
+	// OpenEditor sets it to the archive's existing alignment if its members appear to be padded.
+	Alignment uint32
+
+	path    string
+	file    *os.File
+	closed  bool
+	archive Archive
+	members []editMember
+}
+
+// editMember is a member of the archive being edited.
+type editMember struct {
+	entry TocEntry // Entry in the original archive, or just the hash for new members
+	data  []byte   // Replacement contents, or nil if the original data is kept
+}
+
+// OpenEditor opens the archive at path for editing.
+// Close or Commit must be called once editing is finished.
+func OpenEditor(path string) (*Editor, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := Parse(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	layout, err := archive.Layout()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	e := &Editor{
+		Level:   zlib.DefaultCompression,
+		path:    path,
+		file:    f,
+		archive: archive,
+	}
+
+	if len(layout.Gaps) > 0 && layout.AlignmentPadding() {
+		e.Alignment = layout.Alignment
+	}
+
+	for _, entry := range archive.Entries {
+		e.members = append(e.members, editMember{entry: entry})
+		if entry.Flags == EntryFlagZlibCompression {
+			e.Compress = true
+		}
+	}
+
+	return e, nil
+}
+
+// Entries returns the table of contents as it currently stands.
+// Entries for new or replaced members only have their Hash and Flags set.
+func (e *Editor) Entries() []TocEntry {
+	entries := make([]TocEntry, len(e.members))
+	for i, m := range e.members {
+		entries[i] = m.entry
+	}
+	return entries
+}
+
+// Original returns the archive as it was when the editor was opened, for reading members that have not been replaced.
+// It reads from the editor's open file, so it must not be used once the editor has been closed or committed.
+func (e *Editor) Original() Archive {
+	return e.archive
+}
+
+// find returns the index of the first member with the given hash, or -1.
+func (e *Editor) find(hash Hash) int {
+	for i, m := range e.members {
+		if m.entry.Hash == hash {
+			return i
+		}
+	}
+	return -1
+}
+
+// Add reads a new member from r and stores it under path.
+// ErrDuplicateHash is returned if the archive already contains path.
+func (e *Editor) Add(path string, r io.Reader) error {
+	hash := String2Hash(path)
+	if e.find(hash) >= 0 {
+		return fmt.Errorf("%w: %v (%s)", ErrDuplicateHash, hash, path)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	flags := EntryFlagNoCompression
+	if e.Compress {
+		flags = EntryFlagZlibCompression
+	}
+
+	e.members = append(e.members, editMember{
+		entry: TocEntry{Hash: hash, Flags: flags},
+		data:  data,
+	})
+	return nil
+}
+
+// Replace reads new contents for the member with the given hash from r.
+// If the archive has more than one entry for hash, all of them are replaced.
+// ErrHashNotFound is returned if the archive does not contain hash.
+// Encrypted members cannot be replaced, as jhmod cannot encrypt them.
+func (e *Editor) Replace(hash Hash, r io.Reader) error {
+	indices := []int{}
+	for i, m := range e.members {
+		if m.entry.Hash != hash {
+			continue
+		}
+		if flags := m.entry.Flags; flags != EntryFlagNoCompression && flags != EntryFlagZlibCompression {
+			return fmt.Errorf("%v has flags %v, so it cannot be replaced", hash, flags)
+		}
+		indices = append(indices, i)
+	}
+	if len(indices) == 0 {
+		return fmt.Errorf("%w: %v", ErrHashNotFound, hash)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	for _, i := range indices {
+		e.members[i] = editMember{
+			entry: TocEntry{Hash: hash, Flags: e.members[i].entry.Flags},
+			data:  data,
+		}
+	}
+	return nil
+}
+
+// Remove removes every member with the given hash.
+// ErrHashNotFound is returned if the archive does not contain hash.
+func (e *Editor) Remove(hash Hash) error {
+	kept := e.members[:0]
+	for _, m := range e.members {
+		if m.entry.Hash != hash {
+			kept = append(kept, m)
+		}
+	}
+
+	if len(kept) == len(e.members) {
+		return fmt.Errorf("%w: %v", ErrHashNotFound, hash)
+	}
+	e.members = kept
+	return nil
+}
+
+// Commit writes the edited archive to a temporary file in the same directory as the original,
+// then renames it over the original. The Editor is closed afterwards, even if an error occurs.
+func (e *Editor) Commit() error {
+	return e.CommitTo(e.path)
+}
+
+// CommitTo is like Commit, but writes the edited archive to path, leaving the original untouched
+// unless path refers to it. The new file gets the same permissions as the original.
+func (e *Editor) CommitTo(path string) error {
+	defer e.Close() // Only needed if an error occurs before the original is closed below
+
+	info, err := e.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // Fails harmlessly once the file has been renamed
+
+	if err := e.write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// The original must be closed before it can be renamed over on Windows
+	if err := e.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// write writes the edited archive to w.
+func (e *Editor) write(w io.WriteSeeker) error {
+	writer, err := NewWriter(w, uint32(len(e.members)))
+	if err != nil {
+		return err
+	}
+	writer.Alignment = e.Alignment
+	// Duplicate entries in the original archive are kept; Add does not allow new ones
+	writer.allowDuplicates = true
+
+	// Members in the original archive which shared data still share it in the new one.
+	// copied maps the range of each original member that has been copied to its index in writer's ToC.
+	copied := map[[2]uint32]int{}
+	// replaced maps each replaced hash to its index in writer's ToC, so that duplicate entries share the new data
+	replaced := map[Hash]int{}
+
+	for _, m := range e.members {
+		hash := m.entry.Hash
+
+		if m.data != nil {
+			if idx, exists := replaced[hash]; exists && writer.toc[idx].Flags == m.entry.Flags {
+				if err := writer.link(hash, writer.toc[idx]); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if m.entry.Flags == EntryFlagZlibCompression {
+				_, err = writer.CreateCompressed(bytes.NewReader(m.data), hash, e.Level)
+			} else {
+				_, err = writer.Create(bytes.NewReader(m.data), hash)
+			}
+			if err != nil {
+				return err
+			}
+			replaced[hash] = writer.index - 1
+			continue
+		}
+
+		key := [2]uint32{m.entry.Offset, m.entry.Length}
+		if idx, exists := copied[key]; exists && writer.toc[idx].Flags == m.entry.Flags && writer.toc[idx].RawLength == m.entry.RawLength {
+			if err := writer.link(hash, writer.toc[idx]); err != nil {
+				return err
+			}
+			continue
+		}
+
+		raw, err := e.archive.RawEntry(m.entry)
+		if err != nil {
+			return fmt.Errorf("%v: %w", hash, err)
+		}
+		if _, err := writer.CreateRaw(bytes.NewReader(raw), hash, m.entry.RawLength, m.entry.Flags); err != nil {
+			return err
+		}
+		copied[key] = writer.index - 1
+	}
+
+	return writer.Finalize()
+}
+
+// Close closes the original archive without writing any changes.
+// Calling Close more than once has no effect.
+func (e *Editor) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.file.Close()
+}
@@ -0,0 +1,242 @@
+package nvc
+
+import (
+	"bytes"
+	"compress/zlib"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dsnet/golib/memfile"
+)
+
+func TestEditor(t *testing.T) {
+	files := []file{
+		{"foo", []byte("foobar\n")},
+		{"fox.txt", []byte("The quick brown fox jumps over the lazy dog\n")},
+		{"shared", []byte("foobar\n")},
+	}
+
+	nvcFile := &memfile.File{}
+	writer, err := NewWriter(nvcFile, uint32(len(files)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Dedupe = true
+	for _, f := range files {
+		if _, err := writer.CreateCompressed(bytes.NewReader(f.contents), String2Hash(f.name), zlib.DefaultCompression); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Finalize(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "test.nvc")
+	if err := os.WriteFile(path, nvcFile.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	editor, err := OpenEditor(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.Remove(String2Hash("fox.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.Replace(String2Hash("foo"), bytes.NewReader([]byte("replaced\n"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.Add("new", bytes.NewReader([]byte("new\n"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.Add("shared", bytes.NewReader([]byte("duplicate\n"))); err == nil {
+		t.Fatal("Adding an existing path succeeded")
+	}
+	if err := editor.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	parsed, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []file{
+		{"foo", []byte("replaced\n")},
+		{"shared", []byte("foobar\n")},
+		{"new", []byte("new\n")},
+	}
+	if len(parsed.Entries) != len(expected) {
+		t.Fatalf("Got %d entries, expected %d", len(parsed.Entries), len(expected))
+	}
+	for i, f := range expected {
+		if parsed.Entries[i].Hash != String2Hash(f.name) {
+			t.Fatalf("Got %v at index %d, expected %v", parsed.Entries[i].Hash, i, String2Hash(f.name))
+		}
+		if parsed.Entries[i].Flags != EntryFlagZlibCompression {
+			t.Fatalf("Got flags %v for %s, expected %v", parsed.Entries[i].Flags, f.name, EntryFlagZlibCompression)
+		}
+
+		contents, err := parsed.ReadEntry(parsed.Entries[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Compare(contents, f.contents) != 0 {
+			t.Fatalf("Got %v, expected %v\n", contents, f.contents)
+		}
+	}
+}
+
+func TestEditorKeepsSharedData(t *testing.T) {
+	dataStart := uint32(preambleLen) + 3*uint32(tocEntryLen)
+	entries := []TocEntry{
+		{Hash: String2Hash("foo"), Offset: dataStart, RawLength: 3, Length: 3},
+		{Hash: String2Hash("bar"), Offset: dataStart + 3, RawLength: 3, Length: 3},
+		{Hash: String2Hash("baz"), Offset: dataStart, RawLength: 3, Length: 3},
+	}
+
+	path := filepath.Join(t.TempDir(), "test.nvc")
+	if err := os.WriteFile(path, makeRawNVC(t, entries, []byte("onetwo")).Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	editor, err := OpenEditor(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.Remove(String2Hash("bar")); err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	parsed, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.Entries[0].Offset != parsed.Entries[1].Offset {
+		t.Fatalf("Got offsets %d and %d, expected them to be shared", parsed.Entries[0].Offset, parsed.Entries[1].Offset)
+	}
+	if info, _ := f.Stat(); info.Size() != int64(preambleLen)+2*int64(tocEntryLen)+3 {
+		t.Fatalf("Got archive of %d bytes, expected shared data to be written once", info.Size())
+	}
+}
+
+func TestEditorCommitTo(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.nvc")
+	patched := filepath.Join(dir, "patched.nvc")
+
+	original := makeTestNVC(t, false, file{"foo", []byte("foo\n")}).Bytes()
+	if err := os.WriteFile(path, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	editor, err := OpenEditor(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.Replace(String2Hash("foo"), bytes.NewReader([]byte("bar\n"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.CommitTo(patched); err != nil {
+		t.Fatal(err)
+	}
+
+	if unchanged, _ := os.ReadFile(path); !bytes.Equal(unchanged, original) {
+		t.Fatalf("Got %v, expected the original archive to be unchanged", unchanged)
+	}
+
+	f, err := os.Open(patched)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	parsed, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents, err := parsed.File(String2Hash("foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != "bar\n" {
+		t.Fatalf("Got %q, expected %q", contents, "bar\n")
+	}
+}
+
+func TestEditorDuplicates(t *testing.T) {
+	dataStart := uint32(preambleLen) + 4*uint32(tocEntryLen)
+	hash := String2Hash("foo")
+	entries := []TocEntry{
+		{Hash: hash, Offset: dataStart, RawLength: 3, Length: 3},
+		{Hash: String2Hash("bar"), Offset: dataStart + 3, RawLength: 3, Length: 3},
+		{Hash: hash, Offset: dataStart + 6, RawLength: 3, Length: 3},
+		{Hash: String2Hash("secret"), Offset: dataStart + 9, RawLength: 3, Length: 3, Flags: EntryFlagEncrypted},
+	}
+
+	path := filepath.Join(t.TempDir(), "test.nvc")
+	if err := os.WriteFile(path, makeRawNVC(t, entries, []byte("onetwothrfou")).Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	editor, err := OpenEditor(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.Replace(String2Hash("secret"), bytes.NewReader([]byte("new"))); err == nil {
+		t.Fatal("Replacing an encrypted member succeeded")
+	}
+	if err := editor.Replace(hash, bytes.NewReader([]byte("new"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := editor.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	parsed, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Entries) != 4 {
+		t.Fatalf("Got %d entries, expected 4", len(parsed.Entries))
+	}
+
+	for _, i := range []int{0, 2} {
+		contents, err := parsed.ReadEntry(parsed.Entries[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(contents) != "new" {
+			t.Fatalf("Got %q for entry %d, expected %q", contents, i, "new")
+		}
+	}
+	if parsed.Entries[0].Offset != parsed.Entries[2].Offset {
+		t.Fatalf("Got offsets %d and %d, expected the replaced duplicates to share data", parsed.Entries[0].Offset, parsed.Entries[2].Offset)
+	}
+	if parsed.Entries[3].Flags != EntryFlagEncrypted {
+		t.Fatalf("Got flags %v, expected %v", parsed.Entries[3].Flags, EntryFlagEncrypted)
+	}
+}
@@ -0,0 +1,94 @@
+package nvc
+
+import (
+	"io"
+	"sort"
+)
+
+// maxAlignment is the largest alignment that Layout will report.
+// Offsets which happen to be multiples of larger powers of two are not considered meaningful.
+const maxAlignment = 1 << 16
+
+// Gap is a range of bytes in an archive which does not belong to the header or to any member.
+type Gap struct {
+	Offset uint32
+	Length uint32
+}
+
+// Layout describes how member data is arranged within an archive.
+type Layout struct {
+	Size         int64  // Size of the archive in bytes
+	HeaderLength uint32 // Length of the magic bytes, entry count and table of contents
+	TocLength    uint32 // Length of the table of contents alone
+	Alignment    uint32 // Largest power of two that divides the offset of every non-empty member
+	Gaps         []Gap  // Unused ranges between members, and between the last member and the end of the archive
+}
+
+// Padding returns the total number of bytes in l's gaps.
+func (l Layout) Padding() uint64 {
+	total := uint64(0)
+	for _, g := range l.Gaps {
+		total += uint64(g.Length)
+	}
+	return total
+}
+
+// AlignmentPadding reports whether every gap before a member is shorter than l.Alignment,
+// which is what a writer that aligns members would produce.
+func (l Layout) AlignmentPadding() bool {
+	for _, g := range l.Gaps {
+		if int64(g.Offset)+int64(g.Length) < l.Size && g.Length >= l.Alignment {
+			return false
+		}
+	}
+	return true
+}
+
+// Layout examines the offsets and lengths of a's entries.
+func (a Archive) Layout() (Layout, error) {
+	size, err := a.r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return Layout{}, err
+	}
+
+	l := Layout{
+		Size:      size,
+		TocLength: uint32(tocEntryLen) * uint32(len(a.Entries)),
+	}
+	l.HeaderLength = uint32(preambleLen) + l.TocLength
+
+	sorted := make([]TocEntry, 0, len(a.Entries))
+	offsetBits := uint32(0)
+	for _, e := range a.Entries {
+		if e.Length == 0 {
+			continue
+		}
+		sorted = append(sorted, e)
+		offsetBits |= e.Offset
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Offset < sorted[j].Offset })
+
+	// The lowest set bit across all offsets is the largest power of two dividing each of them
+	l.Alignment = offsetBits & -offsetBits
+	if l.Alignment == 0 || l.Alignment > maxAlignment {
+		l.Alignment = maxAlignment
+	}
+	if len(sorted) == 0 {
+		l.Alignment = 0
+	}
+
+	cursor := int64(l.HeaderLength)
+	for _, e := range sorted {
+		if int64(e.Offset) > cursor {
+			l.Gaps = append(l.Gaps, Gap{uint32(cursor), uint32(int64(e.Offset) - cursor)})
+		}
+		if end := int64(e.Offset) + int64(e.Length); end > cursor {
+			cursor = end
+		}
+	}
+	if size > cursor {
+		l.Gaps = append(l.Gaps, Gap{uint32(cursor), uint32(size - cursor)})
+	}
+
+	return l, nil
+}
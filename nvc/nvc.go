@@ -8,7 +8,9 @@
 package nvc
 
 import (
+	"bytes"
 	"compress/zlib"
+	"crypto/sha256"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -35,11 +37,19 @@ const (
 
 var ErrNoMagicFound error = errors.New("nvc magic bytes not found")
 
+// ErrHashNotFound is returned when a hash is not present in an archive.
+var ErrHashNotFound error = errors.New("hash not present in archive")
+
+// ErrDuplicateHash is returned by Writer when a hash is added to an archive more than once.
+var ErrDuplicateHash error = errors.New("duplicate hash in archive")
+
 type Archive struct {
-	Entries    map[Hash]TocEntry // Map of hashes to table of contents entries
-	EntryOrder []Hash            // List of entry hashes in the order that they are stored in the archive
+	Entries    []TocEntry // Table of contents entries in the order that they are stored in the archive
+	EntryOrder []Hash     // List of entry hashes in the order that they are stored in the archive
+	Duplicates []Hash     // Hashes that appear in the table of contents more than once
 
-	r io.ReadSeeker
+	index map[Hash]int // Maps each hash to the index of its first entry in Entries
+	r     io.ReadSeeker
 }
 
 // Parse reads r and attempts to interpret is as an NVC archive.
@@ -55,8 +65,11 @@ func Parse(r io.ReadSeeker) (Archive, error) {
 		return Archive{}, countErr
 	}
 
-	entries := make(map[Hash]TocEntry)
+	entries := make([]TocEntry, count)
 	order := make([]Hash, count)
+	index := make(map[Hash]int)
+	duplicates := []Hash{}
+	reported := make(map[Hash]bool)
 
 	var i uint32
 	for i = 0; i < count; i++ {
@@ -65,26 +78,80 @@ func Parse(r io.ReadSeeker) (Archive, error) {
 			return Archive{}, eErr
 		}
 
-		entries[entry.Hash] = entry
+		entries[i] = entry
 		order[i] = entry.Hash
+
+		// Only the first entry for a hash is reachable through Entry and File,
+		// but every entry is kept so that nothing in the ToC is silently lost.
+		if _, exists := index[entry.Hash]; !exists {
+			index[entry.Hash] = int(i)
+		} else if !reported[entry.Hash] {
+			duplicates = append(duplicates, entry.Hash)
+			reported[entry.Hash] = true
+		}
 	}
 
 	a := Archive{
 		Entries:    entries,
 		EntryOrder: order,
+		Duplicates: duplicates,
+		index:      index,
 		r:          r,
 	}
 
 	return a, nil
 }
 
-// File returns the data for the file that is reference by hash
+// Entry returns the first table of contents entry for hash.
+func (a Archive) Entry(hash Hash) (TocEntry, bool) {
+	idx, exists := a.index[hash]
+	if !exists {
+		return TocEntry{}, false
+	}
+	return a.Entries[idx], true
+}
+
+// EntriesFor returns every table of contents entry for hash, in archive order.
+// The result has more than one element only if hash is listed in Duplicates.
+func (a Archive) EntriesFor(hash Hash) []TocEntry {
+	found := []TocEntry{}
+	for _, e := range a.Entries {
+		if e.Hash == hash {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+// File returns the data for the file that is reference by hash.
+// If hash appears more than once, the data for its first entry is returned.
 func (a Archive) File(hash Hash) ([]byte, error) {
-	entry, exists := a.Entries[hash]
+	entry, exists := a.Entry(hash)
 	if !exists {
-		return nil, errors.New("hash not present in archive")
+		return nil, ErrHashNotFound
 	}
 
+	return a.ReadEntry(entry)
+}
+
+// RawEntry returns entry's data as it is stored in the archive, without decompressing it.
+func (a Archive) RawEntry(entry TocEntry) ([]byte, error) {
+	_, err := a.r.Seek(int64(entry.Offset), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, entry.Length)
+	readBytes, err := io.ReadFull(a.r, data)
+	if err != nil {
+		return nil, fmt.Errorf("error reading data after %d bytes: %w", readBytes, err)
+	}
+
+	return data, nil
+}
+
+// ReadEntry returns the extracted data for entry, which need not be the first entry for its hash.
+func (a Archive) ReadEntry(entry TocEntry) ([]byte, error) {
 	_, err := a.r.Seek(int64(entry.Offset), 0)
 	if err != nil {
 		return nil, err
@@ -188,6 +255,32 @@ type Writer struct {
 	// files must be known ahead of time. Otherwise, writing the table of contents
 	// would result in member file contents being partially overwritten.
 	index int
+
+	// seen holds every hash that has been added, so that duplicates can be rejected.
+	seen map[Hash]struct{}
+	// allowDuplicates lets a hash be added more than once, so that Editor can keep the duplicate
+	// entries of an existing archive.
+	allowDuplicates bool
+
+	// Dedupe enables content deduplication. When it is true, a member whose contents
+	// (and compression) match an earlier member is not written again; instead its
+	// Table of Contents entry points at the earlier member's data.
+	// Members are read fully into memory while Dedupe is enabled.
+	Dedupe bool
+
+	// Alignment, if greater than 1, causes each member's data to start at an offset
+	// that is a multiple of Alignment. The space before a member is filled with zeros.
+	Alignment uint32
+
+	contents      map[contentKey]TocEntry // First entry written for each distinct content
+	dedupedCount  int                     // Number of members that share data with an earlier member
+	dedupedLength int64                   // Number of bytes that did not need to be written
+}
+
+// contentKey identifies member data for deduplication.
+type contentKey struct {
+	flags EntryFlags
+	sum   [sha256.Size]byte
 }
 
 // NewWriter returns an nvc archive writer that writes to w.
@@ -202,12 +295,43 @@ func NewWriter(w io.WriteSeeker, length uint32) (Writer, error) {
 	}
 
 	return Writer{
-		toc:   make([]TocEntry, length),
-		w:     w,
-		index: 0,
+		toc:      make([]TocEntry, length),
+		w:        w,
+		index:    0,
+		seen:     make(map[Hash]struct{}),
+		contents: make(map[contentKey]TocEntry),
 	}, nil
 }
 
+// DedupeStats returns how many members were deduplicated and how many bytes were saved by doing so.
+func (w *Writer) DedupeStats() (members int, saved int64) {
+	return w.dedupedCount, w.dedupedLength
+}
+
+// findShared reads r fully and looks for an earlier member with the same contents and entry.Flags.
+// If one exists, entry is pointed at its data and shared is true.
+// Otherwise the returned reader yields the contents of r, and key should be passed to
+// w.contents once the member has been written.
+func (w *Writer) findShared(r io.Reader, entry *TocEntry) (contents io.Reader, key contentKey, shared bool, err error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, key, false, err
+	}
+
+	key = contentKey{entry.Flags, sha256.Sum256(data)}
+	if existing, exists := w.contents[key]; exists {
+		entry.Offset = existing.Offset
+		entry.RawLength = existing.RawLength
+		entry.Length = existing.Length
+
+		w.dedupedCount++
+		w.dedupedLength += int64(existing.Length)
+		return nil, key, true, nil
+	}
+
+	return bytes.NewReader(data), key, false, nil
+}
+
 // cumulativeWriter wraps an io.Writer and keeps a running total of how many bytes have been written
 type cumulativeWriter struct {
 	w     io.Writer
@@ -240,27 +364,73 @@ func (r *cumulativeReader) Read(p []byte) (int, error) {
 	return n, err
 }
 
+// align pads w with zeros until its position is a multiple of w.Alignment, and returns the new position.
+func (w *Writer) align() (int64, error) {
+	pos, err := w.w.Seek(0, io.SeekCurrent)
+	if err != nil || w.Alignment <= 1 {
+		return pos, err
+	}
+
+	padding := (int64(w.Alignment) - pos%int64(w.Alignment)) % int64(w.Alignment)
+	if padding == 0 {
+		return pos, nil
+	}
+
+	if _, err := w.w.Write(make([]byte, padding)); err != nil {
+		return pos, err
+	}
+	return pos + padding, nil
+}
+
+// claim checks that a new member with the given hash can be added to w and records the hash as used.
+func (w *Writer) claim(hash Hash) error {
+	if w.index == len(w.toc) {
+		panic("File count exceeds originally specified number")
+	}
+
+	if _, exists := w.seen[hash]; exists && !w.allowDuplicates {
+		return fmt.Errorf("%w: %v", ErrDuplicateHash, hash)
+	}
+	w.seen[hash] = struct{}{}
+
+	return nil
+}
+
 // Create reads an archive member file from r and writes it to w.
 //
 // Create increments w's internal Table of Contents entry counter by 1; it will panic if this counter exceeds the value of "length" that was passed to NewWriter.
+// If hash has already been added to w, ErrDuplicateHash is returned and the counter is left unchanged.
 // This function is not thread-safe; only one archive member file can be written to w at a time.
 func (w *Writer) Create(r io.Reader, hash Hash) (int64, error) {
-	if w.index == len(w.toc) {
-		panic("File count exceeds originally specified number")
+	if err := w.claim(hash); err != nil {
+		return 0, err
 	}
 
 	// Increment index at start of function so it won't get reused in the event of an early return
 	idx := w.index
 	w.index++
 
-	reader := &cumulativeReader{r, 0}
-	currentPos, _ := w.w.Seek(0, io.SeekCurrent)
-
 	var entry *TocEntry = &(w.toc[idx])
 	entry.Hash = hash
-	entry.Offset = uint32(currentPos)
 	entry.Flags = EntryFlagNoCompression
 
+	var key contentKey
+	if w.Dedupe {
+		var shared bool
+		var err error
+		r, key, shared, err = w.findShared(r, entry)
+		if err != nil || shared {
+			return 0, err
+		}
+	}
+
+	reader := &cumulativeReader{r, 0}
+	currentPos, err := w.align()
+	if err != nil {
+		return 0, err
+	}
+	entry.Offset = uint32(currentPos)
+
 	written, err := io.Copy(w.w, reader)
 	if err != nil {
 		return written, err
@@ -270,6 +440,10 @@ func (w *Writer) Create(r io.Reader, hash Hash) (int64, error) {
 	entry.RawLength = uint32(read)
 	entry.Length = uint32(written)
 
+	if w.Dedupe {
+		w.contents[key] = *entry
+	}
+
 	return written, nil
 }
 
@@ -277,16 +451,31 @@ func (w *Writer) Create(r io.Reader, hash Hash) (int64, error) {
 // See the documentation for [compress/zlib] for the acceptable values of level.
 
 // CreateCompressed increments w's internal Table of Contents entry counter by 1; it will panic if this counter exceeds the value of "length" that was passed to NewWriter.
+// If hash has already been added to w, ErrDuplicateHash is returned and the counter is left unchanged.
 // This function is not thread-safe; only one archive member file can be written to w at a time.
 func (w *Writer) CreateCompressed(r io.Reader, hash Hash, level int) (int64, error) {
-	if w.index == len(w.toc) {
-		panic("File count exceeds originally specified number")
+	if err := w.claim(hash); err != nil {
+		return 0, err
 	}
 
 	// Increment index at start of function so it won't get reused in the event of an early return
 	idx := w.index
 	w.index++
 
+	var entry *TocEntry = &(w.toc[idx])
+	entry.Hash = hash
+	entry.Flags = EntryFlagZlibCompression
+
+	var key contentKey
+	if w.Dedupe {
+		var shared bool
+		var err error
+		r, key, shared, err = w.findShared(r, entry)
+		if err != nil || shared {
+			return 0, err
+		}
+	}
+
 	writer := cumulativeWriter{w.w, 0}
 	zWriter, err := zlib.NewWriterLevel(&writer, level)
 	if err != nil {
@@ -294,12 +483,11 @@ func (w *Writer) CreateCompressed(r io.Reader, hash Hash, level int) (int64, err
 	}
 
 	reader := &cumulativeReader{r, 0}
-	currentPos, _ := w.w.Seek(0, io.SeekCurrent)
-
-	var entry *TocEntry = &(w.toc[idx])
-	entry.Hash = hash
+	currentPos, err := w.align()
+	if err != nil {
+		return 0, err
+	}
 	entry.Offset = uint32(currentPos)
-	entry.Flags = EntryFlagZlibCompression
 
 	bytesWritten, err := io.Copy(zWriter, reader)
 	if err != nil {
@@ -314,9 +502,63 @@ func (w *Writer) CreateCompressed(r io.Reader, hash Hash, level int) (int64, err
 	entry.RawLength = uint32(bytesRead)
 	entry.Length = uint32(bytesWritten)
 
+	if w.Dedupe {
+		w.contents[key] = *entry
+	}
+
 	return bytesWritten, nil
 }
 
+// CreateRaw reads an archive member file's stored data from r and writes it to w unchanged.
+// rawLength and flags describe the data in the same way as the fields of a TocEntry,
+// which allows members to be copied from another archive without being extracted and recompressed.
+// Dedupe does not apply to members written with CreateRaw.
+//
+// CreateRaw increments w's internal Table of Contents entry counter by 1; it will panic if this counter exceeds the value of "length" that was passed to NewWriter.
+// If hash has already been added to w, ErrDuplicateHash is returned and the counter is left unchanged.
+// This function is not thread-safe; only one archive member file can be written to w at a time.
+func (w *Writer) CreateRaw(r io.Reader, hash Hash, rawLength uint32, flags EntryFlags) (int64, error) {
+	if err := w.claim(hash); err != nil {
+		return 0, err
+	}
+
+	// Increment index at start of function so it won't get reused in the event of an early return
+	idx := w.index
+	w.index++
+
+	var entry *TocEntry = &(w.toc[idx])
+	entry.Hash = hash
+	entry.Flags = flags
+	entry.RawLength = rawLength
+
+	currentPos, err := w.align()
+	if err != nil {
+		return 0, err
+	}
+	entry.Offset = uint32(currentPos)
+
+	written, err := io.Copy(w.w, r)
+	if err != nil {
+		return written, err
+	}
+	entry.Length = uint32(written)
+
+	return written, nil
+}
+
+// link adds a member to w whose data is the data that was already written for target.
+func (w *Writer) link(hash Hash, target TocEntry) error {
+	if err := w.claim(hash); err != nil {
+		return err
+	}
+
+	target.Hash = hash
+	w.toc[w.index] = target
+	w.index++
+
+	return nil
+}
+
 // Finalize writes the nvc header to the start of w.
 // It is an error to call Create after Finalize has been called.
 func (w *Writer) Finalize() error {
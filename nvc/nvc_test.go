@@ -3,6 +3,8 @@ package nvc
 import (
 	"bytes"
 	"compress/zlib"
+	"encoding/binary"
+	"errors"
 	"io"
 	"testing"
 
@@ -132,3 +134,230 @@ func TestReadCompressed(t *testing.T) {
 	}
 
 }
+
+// makeRawNVC builds an archive by hand so that the table of contents can contain things the Writer refuses to write.
+func makeRawNVC(t *testing.T, entries []TocEntry, data []byte) *memfile.File {
+	nvcFile := &memfile.File{}
+	nvcFile.Write([]byte(magic))
+	if err := binary.Write(nvcFile, binary.LittleEndian, uint32(len(entries))); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if err := binary.Write(nvcFile, binary.LittleEndian, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	nvcFile.Write(data)
+
+	nvcFile.Seek(0, io.SeekStart)
+	return nvcFile
+}
+
+func TestParseDuplicates(t *testing.T) {
+	dataStart := uint32(preambleLen) + 3*uint32(tocEntryLen)
+	hash := String2Hash("foo")
+	entries := []TocEntry{
+		{Hash: hash, Offset: dataStart, RawLength: 3, Length: 3},
+		{Hash: String2Hash("bar"), Offset: dataStart + 3, RawLength: 3, Length: 3},
+		{Hash: hash, Offset: dataStart + 6, RawLength: 3, Length: 3},
+	}
+
+	parsed, err := Parse(makeRawNVC(t, entries, []byte("onetwothr")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(parsed.Entries) != 3 {
+		t.Fatalf("Got %d entries, expected 3", len(parsed.Entries))
+	}
+	if len(parsed.Duplicates) != 1 || parsed.Duplicates[0] != hash {
+		t.Fatalf("Got duplicates %v, expected [%v]", parsed.Duplicates, hash)
+	}
+	if n := len(parsed.EntriesFor(hash)); n != 2 {
+		t.Fatalf("Got %d entries for %v, expected 2", n, hash)
+	}
+
+	first, err := parsed.File(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(first) != "one" {
+		t.Fatalf("Got %q, expected %q", first, "one")
+	}
+
+	second, err := parsed.ReadEntry(parsed.Entries[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(second) != "thr" {
+		t.Fatalf("Got %q, expected %q", second, "thr")
+	}
+}
+
+func TestWriteDuplicateHash(t *testing.T) {
+	writer, err := NewWriter(&memfile.File{}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := writer.Create(bytes.NewReader([]byte("foo")), String2Hash("foo")); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = writer.Create(bytes.NewReader([]byte("bar")), String2Hash("foo"))
+	if !errors.Is(err, ErrDuplicateHash) {
+		t.Fatalf("Got %v, expected %v", err, ErrDuplicateHash)
+	}
+}
+
+func TestWriteDedupe(t *testing.T) {
+	files := []file{
+		{"foo", []byte("shared contents\n")},
+		{"bar", []byte("something else\n")},
+		{"baz", []byte("shared contents\n")},
+	}
+
+	nvcFile := &memfile.File{}
+	writer, err := NewWriter(nvcFile, uint32(len(files)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Dedupe = true
+
+	for _, f := range files {
+		if _, err := writer.CreateCompressed(bytes.NewReader(f.contents), String2Hash(f.name), zlib.DefaultCompression); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Finalize(); err != nil {
+		t.Fatal(err)
+	}
+
+	members, saved := writer.DedupeStats()
+	if members != 1 || saved == 0 {
+		t.Fatalf("Got %d deduplicated members saving %d bytes, expected 1 member", members, saved)
+	}
+
+	nvcFile.Seek(0, io.SeekStart)
+	parsed, err := Parse(nvcFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.Entries[0].Offset != parsed.Entries[2].Offset {
+		t.Fatalf("Got offsets %d and %d, expected them to be shared", parsed.Entries[0].Offset, parsed.Entries[2].Offset)
+	}
+
+	for i, f := range files {
+		contents, err := parsed.ReadEntry(parsed.Entries[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Compare(contents, f.contents) != 0 {
+			t.Fatalf("Got %v, expected %v\n", contents, f.contents)
+		}
+	}
+
+	visited := 0
+	result, err := parsed.VerifyEach(func(i int, data []byte) {
+		visited++
+		if bytes.Compare(data, files[i].contents) != 0 {
+			t.Fatalf("Got %v, expected %v\n", data, files[i].contents)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if visited != len(files) {
+		t.Fatalf("Got %d visited entries, expected %d", visited, len(files))
+	}
+	if result.Shared != 1 || len(result.Problems) != 0 {
+		t.Fatalf("Got %d shared entries and problems %v, expected 1 shared entry and no problems", result.Shared, result.Problems)
+	}
+	if result.SharedLength != saved {
+		t.Fatalf("Got %d shared bytes, expected %d", result.SharedLength, saved)
+	}
+}
+
+func TestVerifyOverlap(t *testing.T) {
+	dataStart := uint32(preambleLen) + 2*uint32(tocEntryLen)
+	entries := []TocEntry{
+		{Hash: String2Hash("foo"), Offset: dataStart, RawLength: 4, Length: 4},
+		{Hash: String2Hash("bar"), Offset: dataStart + 2, RawLength: 4, Length: 4},
+	}
+
+	parsed, err := Parse(makeRawNVC(t, entries, []byte("abcdef")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := parsed.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Shared != 0 || len(result.Problems) != 1 {
+		t.Fatalf("Got %d shared entries and problems %v, expected a single overlap", result.Shared, result.Problems)
+	}
+}
+
+func TestWriteAlignment(t *testing.T) {
+	files := []file{
+		{"foo", []byte("foobar\n")},
+		{"fox.txt", []byte("The quick brown fox jumps over the lazy dog\n")},
+	}
+
+	nvcFile := &memfile.File{}
+	writer, err := NewWriter(nvcFile, uint32(len(files)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Alignment = 16
+
+	for _, f := range files {
+		if _, err := writer.Create(bytes.NewReader(f.contents), String2Hash(f.name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Finalize(); err != nil {
+		t.Fatal(err)
+	}
+
+	nvcFile.Seek(0, io.SeekStart)
+	parsed, err := Parse(nvcFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, f := range files {
+		if parsed.Entries[i].Offset%16 != 0 {
+			t.Fatalf("Got offset %d, expected a multiple of 16", parsed.Entries[i].Offset)
+		}
+
+		contents, err := parsed.ReadEntry(parsed.Entries[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Compare(contents, f.contents) != 0 {
+			t.Fatalf("Got %v, expected %v\n", contents, f.contents)
+		}
+	}
+
+	layout, err := parsed.Layout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if layout.Alignment != 16 || !layout.AlignmentPadding() {
+		t.Fatalf("Got alignment %d with gaps %v, expected 16-byte alignment", layout.Alignment, layout.Gaps)
+	}
+}
+
+func TestReadPathlist(t *testing.T) {
+	pathlist, err := ReadPathlist(bytes.NewBufferString("data/lua/a.lua\ndata/lang/en.csv\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"data/lua/a.lua", "data/lang/en.csv"}
+	if len(pathlist) != len(expected) || pathlist[0] != expected[0] || pathlist[1] != expected[1] {
+		t.Fatalf("Got %v, expected %v", pathlist, expected)
+	}
+}
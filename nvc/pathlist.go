@@ -0,0 +1,17 @@
+package nvc
+
+import (
+	"bufio"
+	"io"
+)
+
+// ReadPathlist reads a pathlist, which names the files in an archive one path per line.
+// Archives only store the hashes of paths, so a pathlist is needed to give their files names.
+func ReadPathlist(r io.Reader) ([]string, error) {
+	pathlist := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		pathlist = append(pathlist, scanner.Text())
+	}
+	return pathlist, scanner.Err()
+}
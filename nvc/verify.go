@@ -0,0 +1,99 @@
+package nvc
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// VerifyResult describes the outcome of Archive.Verify.
+type VerifyResult struct {
+	// Shared is the number of entries whose data is the exact range of an earlier entry,
+	// as written by a Writer with Dedupe enabled. These are not problems.
+	Shared int
+	// SharedLength is the number of bytes that shared entries would have taken up had their data
+	// been written again, which is what deduplication saved.
+	SharedLength int64
+	// Problems lists everything that was found to be wrong with the archive.
+	Problems []error
+}
+
+// Verify checks that every entry's data lies within the archive, can be extracted,
+// and does not partially overlap the data of another entry.
+// Entries which point at exactly the same range as another entry are counted as shared rather than overlapping.
+// The returned error is non-nil only if the archive could not be read at all.
+func (a Archive) Verify() (VerifyResult, error) {
+	return a.VerifyEach(nil)
+}
+
+// VerifyEach is like Verify, but also calls visit with the index in a.Entries and the contents of every entry
+// that could be extracted, so that callers which need the data do not have to decompress the archive a second time.
+func (a Archive) VerifyEach(visit func(i int, data []byte)) (VerifyResult, error) {
+	result := VerifyResult{}
+
+	size, err := a.r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return result, err
+	}
+	headerLen := int64(preambleLen) + int64(tocEntryLen)*int64(len(a.Entries))
+
+	for i, e := range a.Entries {
+		start := int64(e.Offset)
+		end := start + int64(e.Length)
+		if start < headerLen || end > size {
+			result.Problems = append(result.Problems, fmt.Errorf("%v: data at %d-%d is outside of the member area (%d-%d)", e.Hash, start, end, headerLen, size))
+			continue
+		}
+
+		data, err := a.ReadEntry(e)
+		if err != nil {
+			result.Problems = append(result.Problems, fmt.Errorf("%v: %w", e.Hash, err))
+			continue
+		}
+		if visit != nil {
+			visit(i, data)
+		}
+	}
+
+	sorted := make([]TocEntry, len(a.Entries))
+	copy(sorted, a.Entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Offset != sorted[j].Offset {
+			return sorted[i].Offset < sorted[j].Offset
+		}
+		return sorted[i].Length < sorted[j].Length
+	})
+
+	// prev is the entry whose data extends furthest into the archive so far
+	var prev *TocEntry
+	for i := range sorted {
+		e := &sorted[i]
+		if e.Length == 0 {
+			continue
+		}
+
+		if prev != nil && e.Offset < prev.Offset+prev.Length {
+			if sharesRange(*e, *prev) {
+				result.Shared++
+				result.SharedLength += int64(e.Length)
+				continue
+			}
+			result.Problems = append(result.Problems, fmt.Errorf("%v: data at %d-%d overlaps %v at %d-%d",
+				e.Hash, e.Offset, e.Offset+e.Length, prev.Hash, prev.Offset, prev.Offset+prev.Length))
+		}
+
+		if prev == nil || e.Offset+e.Length > prev.Offset+prev.Length {
+			prev = e
+		}
+	}
+
+	return result, nil
+}
+
+// sharesRange reports whether a and b refer to the same stored data.
+func sharesRange(a, b TocEntry) bool {
+	return a.Offset == b.Offset &&
+		a.Length == b.Length &&
+		a.RawLength == b.RawLength &&
+		a.Flags == b.Flags
+}
@@ -0,0 +1,254 @@
+// Package savecatalog keeps an index of save files by game mode, level and seed, so that runs on
+// the same seed can be found and seeds can be shared.
+//
+// A catalog is stored as JSON lines, one Entry per line, sorted by file name.
+package savecatalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/sector-f/jhmod/savefile"
+)
+
+// Entry is the catalog's record of one save file.
+type Entry struct {
+	File         string
+	Size         int64
+	ModTime      time.Time
+	Version      uint32
+	GameMode     string
+	PlayerName   string
+	CurrentLevel string
+	Seed         uint32
+}
+
+// NewEntry returns an Entry for the save s, read from file.
+func NewEntry(file string, info fs.FileInfo, s savefile.Save) Entry {
+	return Entry{
+		File:         file,
+		Size:         info.Size(),
+		ModTime:      info.ModTime(),
+		Version:      s.Version,
+		GameMode:     s.GameMode,
+		PlayerName:   s.PlayerName,
+		CurrentLevel: s.CurrentLevel,
+		Seed:         s.Seed,
+	}
+}
+
+// Catalog is a set of entries, at most one per file.
+type Catalog struct {
+	entries map[string]Entry
+}
+
+// New returns an empty catalog.
+func New() *Catalog {
+	return &Catalog{entries: map[string]Entry{}}
+}
+
+// Read reads a catalog written by Write.
+func Read(r io.Reader) (*Catalog, error) {
+	c := New()
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		e := Entry{}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		c.Add(e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Load reads the catalog at path. If there is no file at path, an empty catalog is returned.
+func Load(path string) (*Catalog, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return New(), nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Read(f)
+}
+
+// Write writes the entries of c to w as JSON lines.
+func (c *Catalog) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, e := range c.Entries() {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Save writes c to path, by way of a temporary file in the same directory.
+func (c *Catalog) Save(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // Fails harmlessly once the file has been renamed
+
+	w := bufio.NewWriter(tmp)
+	if err := c.Write(w); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Add adds e to the catalog, replacing any entry for the same file.
+func (c *Catalog) Add(e Entry) {
+	c.entries[e.File] = e
+}
+
+// Remove removes the entry for file, if there is one.
+func (c *Catalog) Remove(file string) {
+	delete(c.entries, file)
+}
+
+// Len returns the number of entries in the catalog.
+func (c *Catalog) Len() int {
+	return len(c.entries)
+}
+
+// Current reports whether the catalog has an entry for file with the size and modification time in info,
+// in which case the file does not need to be read again.
+func (c *Catalog) Current(file string, info fs.FileInfo) bool {
+	e, ok := c.entries[file]
+	return ok && e.Size == info.Size() && e.ModTime.Equal(info.ModTime())
+}
+
+// Prune removes the entries for files that no longer exist, and returns how many were removed.
+func (c *Catalog) Prune() int {
+	removed := 0
+	for file := range c.entries {
+		if _, err := os.Stat(file); errors.Is(err, os.ErrNotExist) {
+			c.Remove(file)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Entries returns every entry, sorted by file name.
+func (c *Catalog) Entries() []Entry {
+	entries := make([]Entry, 0, len(c.entries))
+	for _, e := range c.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].File < entries[j].File
+	})
+	return entries
+}
+
+// Query selects entries. Empty strings and a nil Seed match everything.
+type Query struct {
+	Seed         *uint32
+	GameMode     string
+	CurrentLevel string
+	PlayerName   string
+}
+
+// Matches reports whether e is selected by q.
+func (q Query) Matches(e Entry) bool {
+	switch {
+	case q.Seed != nil && e.Seed != *q.Seed:
+		return false
+	case q.GameMode != "" && e.GameMode != q.GameMode:
+		return false
+	case q.CurrentLevel != "" && e.CurrentLevel != q.CurrentLevel:
+		return false
+	case q.PlayerName != "" && e.PlayerName != q.PlayerName:
+		return false
+	}
+	return true
+}
+
+// Find returns the entries selected by q, sorted by file name.
+func (c *Catalog) Find(q Query) []Entry {
+	found := []Entry{}
+	for _, e := range c.Entries() {
+		if q.Matches(e) {
+			found = append(found, e)
+		}
+	}
+	return found
+}
+
+// Seed summarizes the saves on one seed in one game mode.
+type Seed struct {
+	GameMode string
+	Seed     uint32
+	Levels   []string
+	Saves    int
+}
+
+// Seeds groups entries by game mode and seed, sorted by game mode and then seed.
+// Levels lists each level that a save was made on once, sorted by name.
+func Seeds(entries []Entry) []Seed {
+	type key struct {
+		mode string
+		seed uint32
+	}
+
+	groups := map[key]*Seed{}
+	levels := map[key]map[string]bool{}
+	for _, e := range entries {
+		k := key{e.GameMode, e.Seed}
+		if groups[k] == nil {
+			groups[k] = &Seed{GameMode: e.GameMode, Seed: e.Seed, Levels: []string{}}
+			levels[k] = map[string]bool{}
+		}
+		groups[k].Saves++
+		if !levels[k][e.CurrentLevel] {
+			levels[k][e.CurrentLevel] = true
+			groups[k].Levels = append(groups[k].Levels, e.CurrentLevel)
+		}
+	}
+
+	seeds := make([]Seed, 0, len(groups))
+	for _, s := range groups {
+		sort.Strings(s.Levels)
+		seeds = append(seeds, *s)
+	}
+	sort.Slice(seeds, func(i, j int) bool {
+		if seeds[i].GameMode != seeds[j].GameMode {
+			return seeds[i].GameMode < seeds[j].GameMode
+		}
+		return seeds[i].Seed < seeds[j].Seed
+	})
+	return seeds
+}
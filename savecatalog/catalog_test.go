@@ -0,0 +1,157 @@
+package savecatalog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testCatalog() *Catalog {
+	c := New()
+	c.Add(Entry{File: "c.sav", GameMode: "jh", CurrentLevel: "Io", Seed: 7})
+	c.Add(Entry{File: "a.sav", GameMode: "jh", CurrentLevel: "Europa", Seed: 7})
+	c.Add(Entry{File: "b.sav", GameMode: "jh", CurrentLevel: "Io", Seed: 3})
+	c.Add(Entry{File: "d.sav", GameMode: "mc", CurrentLevel: "Io", Seed: 7, PlayerName: "Q"})
+	c.Add(Entry{File: "e.sav", GameMode: "jh", CurrentLevel: "Io", Seed: 7})
+	return c
+}
+
+func files(entries []Entry) []string {
+	names := []string{}
+	for _, e := range entries {
+		names = append(names, e.File)
+	}
+	return names
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestReadWrite(t *testing.T) {
+	c := testCatalog()
+	c.Add(Entry{File: "a.sav", GameMode: "jh", CurrentLevel: "Ganymede", Seed: 9, ModTime: time.Unix(100, 0).UTC()})
+
+	buf := &bytes.Buffer{}
+	if err := c.Write(buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if lines := bytes.Count(buf.Bytes(), []byte("\n")); lines != 5 {
+		t.Fatalf("Got %d lines, expected 5", lines)
+	}
+
+	read, err := Read(buf)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	entries := read.Entries()
+	if got, expected := files(entries), []string{"a.sav", "b.sav", "c.sav", "d.sav", "e.sav"}; !equal(got, expected) {
+		t.Fatalf("Got %v, expected %v", got, expected)
+	}
+	if entries[0].CurrentLevel != "Ganymede" || !entries[0].ModTime.Equal(time.Unix(100, 0)) {
+		t.Fatalf("Got %+v, expected the replaced entry", entries[0])
+	}
+
+	if _, err := Read(bytes.NewBufferString("{\"File\":\"a.sav\"}\nnot json\n")); err == nil {
+		t.Fatalf("Got no error for invalid JSON")
+	}
+}
+
+func TestFind(t *testing.T) {
+	c := testCatalog()
+	seven := uint32(7)
+
+	tests := []struct {
+		query    Query
+		expected []string
+	}{
+		{Query{}, []string{"a.sav", "b.sav", "c.sav", "d.sav", "e.sav"}},
+		{Query{Seed: &seven}, []string{"a.sav", "c.sav", "d.sav", "e.sav"}},
+		{Query{Seed: &seven, GameMode: "jh"}, []string{"a.sav", "c.sav", "e.sav"}},
+		{Query{CurrentLevel: "Io", GameMode: "jh"}, []string{"b.sav", "c.sav", "e.sav"}},
+		{Query{PlayerName: "Q"}, []string{"d.sav"}},
+		{Query{GameMode: "none"}, []string{}},
+	}
+	for _, test := range tests {
+		if got := files(c.Find(test.query)); !equal(got, test.expected) {
+			t.Fatalf("Got %v, expected %v", got, test.expected)
+		}
+	}
+}
+
+func TestSeeds(t *testing.T) {
+	seeds := Seeds(testCatalog().Entries())
+	expected := []Seed{
+		{"jh", 3, []string{"Io"}, 1},
+		{"jh", 7, []string{"Europa", "Io"}, 3},
+		{"mc", 7, []string{"Io"}, 1},
+	}
+	if len(seeds) != len(expected) {
+		t.Fatalf("Got %v, expected %v", seeds, expected)
+	}
+	for i := range expected {
+		got := seeds[i]
+		if got.GameMode != expected[i].GameMode || got.Seed != expected[i].Seed || got.Saves != expected[i].Saves || !equal(got.Levels, expected[i].Levels) {
+			t.Fatalf("Got %v, expected %v", got, expected[i])
+		}
+	}
+}
+
+func TestCurrentAndPrune(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.sav")
+	if err := os.WriteFile(path, []byte("save"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := New()
+	if c.Current(path, info) {
+		t.Fatalf("Got current for a file that is not in the catalog")
+	}
+	c.Add(Entry{File: path, Size: info.Size(), ModTime: info.ModTime()})
+	c.Add(Entry{File: filepath.Join(dir, "gone.sav")})
+	if !c.Current(path, info) {
+		t.Fatalf("Got not current for an unchanged file")
+	}
+
+	if err := os.WriteFile(path, []byte("longer save"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(path); c.Current(path, info) {
+		t.Fatalf("Got current for a changed file")
+	}
+
+	if removed := c.Prune(); removed != 1 || c.Len() != 1 {
+		t.Fatalf("Got %d removed and %d left, expected 1 and 1", removed, c.Len())
+	}
+
+	catalog := filepath.Join(dir, "catalog.jsonl")
+	if err := c.Save(catalog); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := Load(catalog)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if got := files(loaded.Entries()); !equal(got, []string{path}) {
+		t.Fatalf("Got %v, expected %v", got, []string{path})
+	}
+
+	if empty, err := Load(filepath.Join(dir, "missing.jsonl")); err != nil || empty.Len() != 0 {
+		t.Fatalf("Got %v, expected an empty catalog", err)
+	}
+}
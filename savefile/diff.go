@@ -0,0 +1,88 @@
+package savefile
+
+// Change is a decoded field whose value differs between two saves.
+type Change struct {
+	Field string
+	A, B  interface{}
+}
+
+// CompareFields returns the decoded fields that differ between a and b.
+func CompareFields(a, b Save) []Change {
+	fields := []Change{
+		{"Version", a.Version, b.Version},
+		{"GameMode", a.GameMode, b.GameMode},
+		{"PlayerName", a.PlayerName, b.PlayerName},
+		{"CurrentLevel", a.CurrentLevel, b.CurrentLevel},
+		{"Seed", a.Seed, b.Seed},
+	}
+
+	changes := []Change{}
+	for _, f := range fields {
+		if f.A != f.B {
+			changes = append(changes, f)
+		}
+	}
+	return changes
+}
+
+// regions are the parts of the header of a decompressed save, in order.
+var regions = []struct {
+	name  string
+	start int
+}{
+	{"Magic", 0},
+	{"Version", versionOffset},
+	{"GameMode", gameModeOffset},
+	{"PlayerName", playerNameOffset},
+	{"CurrentLevel", currentLevelOffset},
+	{SpanHeader, headerOffset},
+	{"Seed", seedOffset},
+	{SpanBody, bodyOffset},
+}
+
+// Region returns the name of the part of a decompressed save that offset falls in,
+// such as "PlayerName", "header" or "body".
+func Region(offset int) string {
+	name := regions[0].name
+	for _, r := range regions {
+		if offset >= r.start {
+			name = r.name
+		}
+	}
+	return name
+}
+
+// ByteRange is a run of bytes in a decompressed save.
+type ByteRange struct {
+	Offset int
+	Length int
+}
+
+// End returns the offset just past the end of r.
+func (r ByteRange) End() int {
+	return r.Offset + r.Length
+}
+
+// DiffBytes returns the ranges of bytes that differ between a and b.
+// Ranges separated by fewer than gap equal bytes are merged, and bytes past the end of the shorter
+// slice count as different.
+func DiffBytes(a, b []byte, gap int) []ByteRange {
+	size := len(a)
+	if len(b) > size {
+		size = len(b)
+	}
+
+	ranges := []ByteRange{}
+	for i := 0; i < size; i++ {
+		if i < len(a) && i < len(b) && a[i] == b[i] {
+			continue
+		}
+
+		if n := len(ranges); n > 0 && i-ranges[n-1].End() < gap {
+			ranges[n-1].Length = i + 1 - ranges[n-1].Offset
+		} else {
+			ranges = append(ranges, ByteRange{i, 1})
+		}
+	}
+	return ranges
+}
@@ -0,0 +1,71 @@
+package savefile
+
+import (
+	"testing"
+)
+
+func TestCompareFields(t *testing.T) {
+	a := Save{Version: 0xa9, GameMode: "jh", PlayerName: "A", CurrentLevel: "Io", Seed: 1}
+	b := a
+	b.PlayerName = "B"
+	b.Seed = 2
+
+	changes := CompareFields(a, b)
+	expected := []Change{{"PlayerName", "A", "B"}, {"Seed", uint32(1), uint32(2)}}
+	if len(changes) != len(expected) {
+		t.Fatalf("Got %v, expected %v", changes, expected)
+	}
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Fatalf("Got %v, expected %v", changes[i], expected[i])
+		}
+	}
+
+	if changes := CompareFields(a, a); len(changes) != 0 {
+		t.Fatalf("Got %v, expected no changes", changes)
+	}
+}
+
+func TestRegion(t *testing.T) {
+	tests := map[int]string{
+		0:                     "Magic",
+		versionOffset:         "Version",
+		playerNameOffset + 31: "PlayerName",
+		currentLevelOffset:    "CurrentLevel",
+		headerOffset + 31:     SpanHeader,
+		seedOffset + 3:        "Seed",
+		bodyOffset + 100000:   SpanBody,
+		gameModeOffset + 0x10: "GameMode",
+	}
+	for offset, expected := range tests {
+		if got := Region(offset); got != expected {
+			t.Fatalf("Got %s for 0x%x, expected %s", got, offset, expected)
+		}
+	}
+}
+
+func TestDiffBytes(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		gap      int
+		expected []ByteRange
+	}{
+		{"same", "same", 4, []ByteRange{}},
+		{"abcdefghij", "abXdefgXij", 1, []ByteRange{{2, 1}, {7, 1}}},
+		{"abcdefghij", "abXdefgXij", 5, []ByteRange{{2, 6}}},
+		{"abc", "abcdef", 4, []ByteRange{{3, 3}}},
+		{"Xbcdef", "abc", 1, []ByteRange{{0, 1}, {3, 3}}},
+	}
+
+	for _, test := range tests {
+		got := DiffBytes([]byte(test.a), []byte(test.b), test.gap)
+		if len(got) != len(test.expected) {
+			t.Fatalf("Got %v, expected %v", got, test.expected)
+		}
+		for i := range got {
+			if got[i] != test.expected[i] {
+				t.Fatalf("Got %v, expected %v", got, test.expected)
+			}
+		}
+	}
+}
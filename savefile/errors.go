@@ -0,0 +1,50 @@
+package savefile
+
+import "fmt"
+
+// ParseError describes where and why a save file could not be parsed.
+type ParseError struct {
+	// Name of the field being read, such as "PlayerName".
+	Field string
+	// Offset of the field from the start of the decompressed save.
+	Offset int
+	// Description of the problem.
+	Reason string
+	// Underlying error, such as ErrNoMagicFound or io.ErrUnexpectedEOF, if there is one.
+	Err error
+
+	data []byte // Decompressed save
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at 0x%x: %s", e.Field, e.Offset, e.Reason)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is SaveParseErr, so that code checking for it keeps working.
+func (e *ParseError) Is(target error) bool {
+	return target == SaveParseErr
+}
+
+// Context returns up to n bytes of the decompressed save on either side of the error's offset,
+// along with the offset of the first byte returned. The start is rounded down to a multiple of 16
+// so that the bytes line up in a hex dump.
+func (e *ParseError) Context(n int) (int, []byte) {
+	start := e.Offset - n
+	if start < 0 {
+		start = 0
+	}
+	start -= start % 16
+
+	end := e.Offset + n
+	if end > len(e.data) {
+		end = len(e.data)
+	}
+	if start > end {
+		return end, nil
+	}
+	return start, e.data[start:end]
+}
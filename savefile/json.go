@@ -0,0 +1,35 @@
+package savefile
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// spanJSON is the JSON form of a Span, with its data as a hexadecimal string.
+type spanJSON struct {
+	Name   string
+	Offset int
+	Data   string
+}
+
+// MarshalJSON encodes s with its data as a hexadecimal string, which is easier to read and diff than base64.
+func (s Span) MarshalJSON() ([]byte, error) {
+	return json.Marshal(spanJSON{s.Name, s.Offset, hex.EncodeToString(s.Data)})
+}
+
+// UnmarshalJSON decodes a Span encoded by MarshalJSON.
+func (s *Span) UnmarshalJSON(data []byte) error {
+	j := spanJSON{}
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	decoded, err := hex.DecodeString(j.Data)
+	if err != nil {
+		return fmt.Errorf("span %q: %w", j.Name, err)
+	}
+
+	*s = Span{j.Name, j.Offset, decoded}
+	return nil
+}
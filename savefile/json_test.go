@@ -0,0 +1,38 @@
+package savefile
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	parsed, err := Parse(bytes.NewReader(makeSave(t, testSave{version: 0xa9, gameMode: "jh", name: "Player", level: "Io", seed: 3, body: []byte{0xde, 0xad}})))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte(`"Data":"dead"`)) {
+		t.Fatalf("Got %s, expected span data as hex", data)
+	}
+
+	decoded := Save{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	// The original compressed file is not part of the JSON
+	parsed.compressed = nil
+	parsed.payloadSum = [32]byte{}
+	if !reflect.DeepEqual(decoded, parsed) {
+		t.Fatalf("Got %+v, expected %+v", decoded, parsed)
+	}
+
+	if err := json.Unmarshal([]byte(`{"Unknown": [{"Name": "body", "Data": "xyz"}]}`), &decoded); err == nil {
+		t.Fatal("Decoding invalid hex succeeded")
+	}
+}
@@ -3,11 +3,14 @@ package savefile
 import (
 	"bytes"
 	"compress/zlib"
+	"crypto/sha256"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
-	"unicode"
+	"strings"
+	"unicode/utf8"
 )
 
 const (
@@ -15,7 +18,45 @@ const (
 	magic = "\xde\xc0\xad\xde"
 )
 
-type savedata struct {
+// Layout of the header of a decompressed save.
+const (
+	versionOffset      = len(magic)
+	gameModeOffset     = versionOffset + 4
+	gameModeSize       = 32
+	playerNameOffset   = gameModeOffset + gameModeSize
+	playerNameSize     = 32
+	currentLevelOffset = playerNameOffset + playerNameSize
+	currentLevelSize   = 64
+	headerOffset       = currentLevelOffset + currentLevelSize
+	headerSize         = 32
+	seedOffset         = headerOffset + headerSize
+	bodyOffset         = seedOffset + 4
+)
+
+// Names of the Spans that Parse produces.
+const (
+	SpanGameModePadding     = "GameMode padding"
+	SpanPlayerNamePadding   = "PlayerName padding"
+	SpanCurrentLevelPadding = "CurrentLevel padding"
+	SpanHeader              = "header"
+	SpanBody                = "body"
+)
+
+// Span is a region of a decompressed save file whose meaning is not known.
+// Spans are kept so that nothing is lost when a save is written back.
+type Span struct {
+	// Short description of the region, such as "body".
+	Name string
+	// Offset of the region from the start of the decompressed save.
+	Offset int
+	// Contents of the region.
+	Data []byte
+}
+
+// Save is the contents of a save file.
+type Save struct {
+	// Version of the save format.  Only the versions in KnownVersions have been seen.
+	Version uint32
 	// Player name
 	PlayerName string
 	// Game mode.  This can be "jh", and various others.
@@ -24,12 +65,82 @@ type savedata struct {
 	CurrentLevel string
 	// The seed used to generate the game.
 	Seed uint32
+	// Regions that have not been decoded, in the order they appear in the file.
+	// These are the 32 bytes between the current level's name and the seed, the rest of the
+	// save after the seed (player, inventory and level state), and any non-zero bytes after
+	// the end of a string in its field.  Strings that are not valid UTF-8 are also kept here,
+	// named after their field, so that they can be written back unchanged.
+	Unknown []Span
+	// Problems that did not stop the save from being read, such as strings that are not valid UTF-8.
+	Warnings []*ParseError `json:"-"`
+	// HeaderOnly is true if the save was read by ParseHeader, in which case the body is missing.
+	HeaderOnly bool `json:"-"`
+
+	// The file the save was parsed from, and the SHA-256 of its decompressed contents,
+	// so that Write can give back the same file if nothing has changed.
+	compressed []byte
+	payloadSum [sha256.Size]byte
+}
+
+// KnownVersions are the save format versions that have been seen in save files written by the game.
+var KnownVersions = []uint32{0xa2, 0xa9}
+
+// KnownVersion returns true if the save's format version is one of KnownVersions.
+// The fields of saves with other versions may not have been read correctly.
+func (s Save) KnownVersion() bool {
+	for _, v := range KnownVersions {
+		if s.Version == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Span returns the unknown region with the given name.
+func (s Save) Span(name string) (Span, bool) {
+	for _, span := range s.Unknown {
+		if span.Name == name {
+			return span, true
+		}
+	}
+	return Span{}, false
+}
+
+// HeaderWords returns the unknown 32 bytes before the seed as little endian 32-bit words,
+// which makes it easier to look for counters and timestamps.
+//
+// Of the header, only the format version is decoded. Which of these words, if any, hold the
+// timestamps, difficulty, character class or turn count has not been worked out, so they have
+// no fields of their own; once one is identified it should become a field of Save.
+func (s Save) HeaderWords() [8]uint32 {
+	words := [8]uint32{}
+	span, _ := s.Span(SpanHeader)
+	for i := range words {
+		if len(span.Data) >= 4*i+4 {
+			words[i] = binary.LittleEndian.Uint32(span.Data[4*i:])
+		}
+	}
+	return words
+}
+
+// Body returns the undecoded part of the save after the seed.
+//
+// The player's stats, inventory, equipment and perks, the level state and the RNG state are all
+// in here, but their layout is not known, so the body is kept as a single span rather than split
+// into fields. Writing a save back copies it unchanged.
+func (s Save) Body() []byte {
+	span, _ := s.Span(SpanBody)
+	return span.Data
 }
 
 // No magic found on save file.
+//
+// Parse returns it wrapped in a *ParseError that gives the bytes found instead, so check for it
+// with errors.Is rather than comparing errors directly.
 var ErrNoMagicFound error = errors.New("no magic found")
 
-// General parse error.  TODO make more descriptive errors.
+// SaveParseErr matches every *ParseError when used with errors.Is.
+// It is kept for code written before ParseError was added.
 var SaveParseErr error = errors.New("general parse error")
 
 // Return the last byte of buf or panic() when not possible.
@@ -40,98 +151,219 @@ func lastByte(buf []byte) byte {
 	return buf[len(buf)-1]
 }
 
-// Returns true if byte looks like ascii, false otherwise.
-func isAscii(b byte) bool {
-	return b > 0 && b <= unicode.MaxASCII
+// isZero returns true if every byte of buf is zero.
+func isZero(buf []byte) bool {
+	for _, b := range buf {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// reader reads the fields of a decompressed save, reporting problems as ParseErrors.
+type reader struct {
+	data     []byte
+	pos      int
+	warnings []*ParseError // Problems that did not stop the save from being read
+}
+
+func (r *reader) fail(field string, offset int, err error, format string, args ...interface{}) *ParseError {
+	return &ParseError{
+		Field:  field,
+		Offset: offset,
+		Reason: fmt.Sprintf(format, args...),
+		Err:    err,
+		data:   r.data,
+	}
+}
+
+// next returns the next n bytes, which hold field.
+func (r *reader) next(field string, n int) ([]byte, error) {
+	if n > len(r.data)-r.pos {
+		return nil, r.fail(field, r.pos, io.ErrUnexpectedEOF, "needs %d bytes, but the save ends after %d", n, len(r.data)-r.pos)
+	}
+	buf := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return buf, nil
 }
 
-// readString reads size bytes from r, interprets the final byte read as an unsigned integer `n`, and returns a string consisting of the first n bytes that were read from r
-func readString(r io.Reader, size uint) (string, error) {
-	buf := make([]byte, size)
-	if _, err := io.ReadFull(r, buf[:]); err != nil {
-		return "", err
+func (r *reader) uint32(field string) (uint32, error) {
+	buf, err := r.next(field, 4)
+	if err != nil {
+		return 0, err
 	}
+	return binary.LittleEndian.Uint32(buf), nil
+}
+
+// stringField is a fixed-width string field read by reader.string.
+type stringField struct {
+	value   string
+	raw     []byte // The bytes of the string, if they were not valid UTF-8
+	padding []byte // The bytes between the end of the string and the length byte
+}
+
+// string reads a size byte field, interprets the final byte as an unsigned integer `n`, and returns a string consisting of the first n bytes of the field.
+// Strings are decoded as UTF-8. If a string is not valid UTF-8, a warning is recorded and the invalid bytes are replaced.
+func (r *reader) string(field string, size int) (stringField, error) {
+	start := r.pos
+	buf, err := r.next(field, size)
+	if err != nil {
+		return stringField{}, err
+	}
+
 	recordedSize := lastByte(buf)
-	if uint(recordedSize) > size {
-		return "", SaveParseErr
+	if int(recordedSize) > size {
+		return stringField{}, r.fail(field, start, nil, "length byte 0x%02x exceeds field width %d", recordedSize, size)
+	} else if int(recordedSize) == size {
+		// The string would include its own length byte, which could not be written back
+		return stringField{}, r.fail(field, start, nil, "length byte 0x%02x leaves no room for itself in field width %d", recordedSize, size)
 	}
 	stringBytes := buf[:recordedSize]
 
-	for _, b := range stringBytes {
-		if !isAscii(b) {
-			return "", SaveParseErr
-		}
+	if i := bytes.IndexByte(stringBytes, 0); i >= 0 {
+		return stringField{}, r.fail(field, start, nil, "NUL byte at 0x%x within the string", start+i)
+	}
+
+	f := stringField{value: string(stringBytes), padding: []byte{}}
+	if int(recordedSize) < len(buf)-1 {
+		f.padding = buf[recordedSize : len(buf)-1]
 	}
-	// TODO verify valid text.
 
-	return string(stringBytes), nil
+	if !utf8.Valid(stringBytes) {
+		r.warnings = append(r.warnings, r.fail(field, start, nil, "not valid UTF-8, so invalid bytes were replaced with U+FFFD"))
+		f.value = decodeLossy(stringBytes)
+		f.raw = stringBytes
+	}
+	return f, nil
 }
 
-// Advance the reader r by size bytes.
-func discard(r io.Reader, size int) error {
-	buf := make([]byte, size)
-	_, err := io.ReadFull(r, buf[:])
-	return err
+// decodeLossy decodes buf as UTF-8, replacing invalid bytes with U+FFFD.
+func decodeLossy(buf []byte) string {
+	return strings.ToValidUTF8(string(buf), string(utf8.RuneError))
 }
 
 // Attempt to read in a save file.
 //
 // If the error value is non-nil, the return value does not contain valid data.
-func Parse(r io.Reader) (savedata, error) {
-	z, zErr := zlib.NewReader(r)
+// Problems with the decompressed contents of the save are reported as a *ParseError,
+// which wraps errors such as ErrNoMagicFound for use with errors.Is.
+//
+// Parse is the same as ParseFull.
+func Parse(r io.Reader) (Save, error) {
+	return ParseFull(r)
+}
+
+// ParseFull reads in a whole save file, keeping the parts after the header in the "body" span.
+func ParseFull(r io.Reader) (Save, error) {
+	compressed, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Save{}, err
+	}
+
+	z, zErr := zlib.NewReader(bytes.NewReader(compressed))
 	if zErr != nil {
-		return savedata{}, zErr
+		return Save{}, zErr
 	}
 	uncompressed, uErr := ioutil.ReadAll(z)
 	if uErr != nil {
-		return savedata{}, uErr
+		return Save{}, uErr
+	}
+
+	save, err := parse(uncompressed, false)
+	if err != nil {
+		return Save{}, err
+	}
+	save.compressed = compressed
+	save.payloadSum = sha256.Sum256(uncompressed)
+	return save, nil
+}
+
+// ParseHeader reads the header of a save file, which holds every field that has been decoded,
+// without decompressing the rest. This is much faster than ParseFull for large saves, but the
+// zlib checksum at the end of the file is not checked.
+//
+// The returned Save has no "body" span, so it cannot be written back with Write.
+func ParseHeader(r io.Reader) (Save, error) {
+	z, zErr := zlib.NewReader(r)
+	if zErr != nil {
+		return Save{}, zErr
+	}
+
+	header := make([]byte, bodyOffset)
+	n, err := io.ReadFull(z, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return Save{}, err
 	}
-	b := bytes.NewReader(uncompressed)
+	// A save that is too short is reported by parse, along with the field that is cut off
+	return parse(header[:n], true)
+}
+
+// parse decodes a decompressed save. If headerOnly is true, data only holds the header.
+func parse(uncompressed []byte, headerOnly bool) (Save, error) {
+	b := &reader{data: uncompressed}
 
-	header := [len(magic)]byte{}
-	if _, err := io.ReadFull(b, header[:]); err != nil {
-		return savedata{}, err
+	header, err := b.next("Magic", len(magic))
+	if err != nil {
+		return Save{}, err
 	}
-	if string(header[:]) != magic {
-		return savedata{}, ErrNoMagicFound
+	if string(header) != magic {
+		return Save{}, b.fail("Magic", 0, ErrNoMagicFound, "expected % x, found % x", magic, header)
 	}
 
-	// Not sure what these bytes are, sometimes they're:
+	save := Save{}
+
+	// Assumed to be the format version, since it changes between game releases:
 	//  a900 0000
 	//  a200 0000
-	if err := discard(b, 4); err != nil {
-		return savedata{}, err
+	if save.Version, err = b.uint32("Version"); err != nil {
+		return Save{}, err
 	}
 
-	gameMode, gameModeErr := readString(b, 32)
-	if gameModeErr != nil {
-		return savedata{}, gameModeErr
+	fields := []struct {
+		name string
+		dest *string
+		size int
+		span string
+	}{
+		{"GameMode", &save.GameMode, gameModeSize, SpanGameModePadding},
+		{"PlayerName", &save.PlayerName, playerNameSize, SpanPlayerNamePadding},
+		{"CurrentLevel", &save.CurrentLevel, currentLevelSize, SpanCurrentLevelPadding},
 	}
-
-	name, nameErr := readString(b, 32)
-	if nameErr != nil {
-		return savedata{}, nameErr
+	for _, s := range fields {
+		start := b.pos
+		f, err := b.string(s.name, s.size)
+		if err != nil {
+			return Save{}, err
+		}
+		*s.dest = f.value
+		if f.raw != nil {
+			save.Unknown = append(save.Unknown, Span{s.name, start, f.raw})
+		}
+		if !isZero(f.padding) {
+			save.Unknown = append(save.Unknown, Span{s.span, start + s.size - 1 - len(f.padding), f.padding})
+		}
 	}
+	save.Warnings = b.warnings
 
-	cur, curErr := readString(b, 64)
-	if curErr != nil {
-		return savedata{}, curErr
+	// Not sure what these 32 bytes are...
+	start := b.pos
+	headerUnknown, err := b.next(SpanHeader, headerSize)
+	if err != nil {
+		return Save{}, err
 	}
+	save.Unknown = append(save.Unknown, Span{SpanHeader, start, headerUnknown})
 
-	// Not sure what these 32 bytes are...
-	if err := discard(b, 32); err != nil {
-		return savedata{}, err
+	if save.Seed, err = b.uint32("Seed"); err != nil {
+		return Save{}, err
 	}
 
-	seed := uint32(0)
-	if err := binary.Read(b, binary.LittleEndian, &seed); err != nil {
-		return savedata{}, err
+	// The rest of the save has not been decoded yet
+	if headerOnly {
+		save.HeaderOnly = true
+	} else {
+		save.Unknown = append(save.Unknown, Span{SpanBody, b.pos, uncompressed[b.pos:]})
 	}
 
-	return savedata{
-		GameMode:     gameMode,
-		PlayerName:   name,
-		CurrentLevel: cur,
-		Seed:         seed,
-	}, nil
+	return save, nil
 }
@@ -0,0 +1,305 @@
+package savefile
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+// testSave describes a synthetic save file.
+type testSave struct {
+	version       uint32
+	gameMode      string
+	name          string
+	level         string
+	headerUnknown [32]byte
+	seed          uint32
+	body          []byte
+}
+
+// writeField writes s to a field of size bytes, with the length of s in the last byte.
+func writeField(buf *bytes.Buffer, s string, size int) {
+	field := make([]byte, size)
+	copy(field, s)
+	field[size-1] = byte(len(s))
+	buf.Write(field)
+}
+
+// makePayload builds the decompressed contents of a save file.
+func makePayload(s testSave) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(magic)
+	binary.Write(buf, binary.LittleEndian, s.version)
+	writeField(buf, s.gameMode, 32)
+	writeField(buf, s.name, 32)
+	writeField(buf, s.level, 64)
+	buf.Write(s.headerUnknown[:])
+	binary.Write(buf, binary.LittleEndian, s.seed)
+	buf.Write(s.body)
+	return buf.Bytes()
+}
+
+// compress zlib-compresses payload, as the game does with save files.
+func compress(t *testing.T, payload []byte) []byte {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	z := zlib.NewWriter(buf)
+	if _, err := z.Write(payload); err != nil {
+		t.Fatal(err)
+	}
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func makeSave(t *testing.T, s testSave) []byte {
+	t.Helper()
+	return compress(t, makePayload(s))
+}
+
+func TestParse(t *testing.T) {
+	unknown := [32]byte{}
+	binary.LittleEndian.PutUint32(unknown[4:], 1234)
+	unknown[31] = 0xff
+
+	tests := []testSave{
+		{version: 0xa9, gameMode: "jh", name: "Player", level: "Callisto L1", seed: 12345},
+		{version: 0xa2, gameMode: "jh_angel", name: "", level: "Europa Dig Zone", seed: 0xffffffff, headerUnknown: unknown},
+		{version: 0xa9, gameMode: "jh", name: "0123456789abcdefghijklmnopqrstu", level: "Io", seed: 1, body: []byte("rest of the save")},
+	}
+
+	for _, test := range tests {
+		parsed, err := Parse(bytes.NewReader(makeSave(t, test)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if parsed.Version != test.version || parsed.GameMode != test.gameMode || parsed.PlayerName != test.name || parsed.CurrentLevel != test.level || parsed.Seed != test.seed {
+			t.Fatalf("Got %+v, expected %+v", parsed, test)
+		}
+		if span, _ := parsed.Span(SpanHeader); span.Offset != 136 || !bytes.Equal(span.Data, test.headerUnknown[:]) {
+			t.Fatalf("Got header span %+v, expected %v at 136", span, test.headerUnknown)
+		}
+		if span, _ := parsed.Span(SpanBody); span.Offset != 172 || !bytes.Equal(parsed.Body(), test.body) {
+			t.Fatalf("Got body span %+v, expected %q at 172", span, test.body)
+		}
+		if !parsed.KnownVersion() {
+			t.Fatalf("Got unknown version for 0x%x", parsed.Version)
+		}
+	}
+}
+
+func TestParseUnknownVersion(t *testing.T) {
+	parsed, err := Parse(bytes.NewReader(makeSave(t, testSave{version: 0xb0, gameMode: "jh"})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.KnownVersion() {
+		t.Fatalf("Got known version for 0x%x", parsed.Version)
+	}
+}
+
+func TestHeaderWords(t *testing.T) {
+	data := make([]byte, 32)
+	binary.LittleEndian.PutUint32(data[4:], 1234)
+	binary.LittleEndian.PutUint32(data[28:], 0xdeadbeef)
+	s := Save{Unknown: []Span{{SpanHeader, 136, data}}}
+
+	expected := [8]uint32{0, 1234, 0, 0, 0, 0, 0, 0xdeadbeef}
+	if got := s.HeaderWords(); got != expected {
+		t.Fatalf("Got %v, expected %v", got, expected)
+	}
+}
+
+func TestParsePadding(t *testing.T) {
+	payload := makePayload(testSave{version: 0xa9, gameMode: "jh", name: "Player", level: "Callisto"})
+	copy(payload[40+len("Player"):], "stale")
+
+	parsed, err := Parse(bytes.NewReader(compress(t, payload)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(parsed.Unknown) != 3 {
+		t.Fatalf("Got %d unknown spans, expected padding, header and body", len(parsed.Unknown))
+	}
+	span := parsed.Unknown[0]
+	if span.Name != SpanPlayerNamePadding || span.Offset != 46 || len(span.Data) != 25 || string(span.Data[:5]) != "stale" {
+		t.Fatalf("Got %+v, expected the name's padding", span)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	valid := makePayload(testSave{version: 0xa9, gameMode: "jh", name: "Player", level: "Callisto"})
+
+	badMagic := append([]byte{}, valid...)
+	badMagic[0] = 0
+
+	longString := append([]byte{}, valid...)
+	longString[playerNameOffset+31] = 0x41
+
+	fullString := append([]byte{}, valid...)
+	fullString[playerNameOffset+31] = 32
+
+	nul := append([]byte{}, valid...)
+	nul[currentLevelOffset+2] = 0
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected error
+		message  string
+	}{
+		{"bad magic", compress(t, badMagic), ErrNoMagicFound, "Magic at 0x0: expected de c0 ad de, found 00 c0 ad de"},
+		{"string too long", compress(t, longString), SaveParseErr, "PlayerName at 0x28: length byte 0x41 exceeds field width 32"},
+		{"string covers its length", compress(t, fullString), SaveParseErr, "PlayerName at 0x28: length byte 0x20 leaves no room for itself in field width 32"},
+		{"nul in string", compress(t, nul), SaveParseErr, "CurrentLevel at 0x48: NUL byte at 0x4a within the string"},
+		{"truncated", compress(t, valid[:100]), io.ErrUnexpectedEOF, "CurrentLevel at 0x48: needs 64 bytes, but the save ends after 28"},
+		{"not compressed", valid, nil, ""},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(bytes.NewReader(test.data))
+		if err == nil {
+			t.Fatalf("%s: parsing succeeded", test.name)
+		}
+		if test.expected != nil && !errors.Is(err, test.expected) {
+			t.Fatalf("%s: got %v, expected %v", test.name, err, test.expected)
+		}
+
+		parseErr := &ParseError{}
+		if errors.As(err, &parseErr) != (test.message != "") {
+			t.Fatalf("%s: got %#v, expected a ParseError only for problems with the decompressed save", test.name, err)
+		}
+		if test.message != "" && err.Error() != test.message {
+			t.Fatalf("%s: got %q, expected %q", test.name, err.Error(), test.message)
+		}
+	}
+}
+
+func TestParseErrorContext(t *testing.T) {
+	data := make([]byte, 100)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	tests := []struct {
+		offset, n     int
+		start, length int
+	}{
+		{40, 16, 16, 40},
+		{4, 16, 0, 20},
+		{90, 16, 64, 36},
+		{200, 16, 100, 0},
+	}
+
+	for _, test := range tests {
+		e := &ParseError{Offset: test.offset, data: data}
+		start, context := e.Context(test.n)
+		if start != test.start || len(context) != test.length {
+			t.Fatalf("Got %d bytes at %d, expected %d at %d", len(context), start, test.length, test.start)
+		}
+		if len(context) > 0 && context[0] != byte(start) {
+			t.Fatalf("Got context starting with %d, expected %d", context[0], start)
+		}
+	}
+}
+
+func TestParseUnicode(t *testing.T) {
+	tests := []struct {
+		name  string
+		level string
+	}{
+		{"Zoë", "Каллисто"},
+		{"Ł" + string(bytes.Repeat([]byte("x"), 29)), "Europa"}, // Multi-byte rune at the start of a full field
+		{string(bytes.Repeat([]byte("x"), 29)) + "ł", "Io"},     // Multi-byte rune ending at the last byte before the length
+		{"日本語のなまえ", string(bytes.Repeat([]byte("界"), 21))},
+	}
+
+	for _, test := range tests {
+		parsed, err := Parse(bytes.NewReader(makeSave(t, testSave{version: 0xa9, gameMode: "jh", name: test.name, level: test.level})))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.PlayerName != test.name || parsed.CurrentLevel != test.level {
+			t.Fatalf("Got %q and %q, expected %q and %q", parsed.PlayerName, parsed.CurrentLevel, test.name, test.level)
+		}
+		if len(parsed.Warnings) != 0 {
+			t.Fatalf("Got warnings %v, expected none", parsed.Warnings)
+		}
+	}
+}
+
+func TestParseInvalidUTF8(t *testing.T) {
+	// The length byte cuts the last rune of the name in half
+	payload := makePayload(testSave{version: 0xa9, gameMode: "jh", name: "Zoë", level: "Io"})
+	payload[playerNameOffset+31] = byte(len("Zoë") - 1)
+
+	parsed, err := Parse(bytes.NewReader(compress(t, payload)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.PlayerName != "Zo\uFFFD" {
+		t.Fatalf("Got %q, expected %q", parsed.PlayerName, "Zo\uFFFD")
+	}
+	if len(parsed.Warnings) != 1 || parsed.Warnings[0].Field != "PlayerName" || parsed.Warnings[0].Offset != playerNameOffset {
+		t.Fatalf("Got warnings %v, expected one for PlayerName", parsed.Warnings)
+	}
+	if span, ok := parsed.Span("PlayerName"); !ok || string(span.Data) != "Zo\xc3" {
+		t.Fatalf("Got span %+v, expected the original bytes of the name", span)
+	}
+
+	// Unchanged names are written back as they were read
+	written, err := parsed.Payload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(written, payload) {
+		t.Fatalf("Got %v, expected %v", written, payload)
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	// Incompressible, so that cutting the compressed save in half cuts the body short
+	body := make([]byte, 1<<16)
+	state := uint32(1)
+	for i := range body {
+		state = state*1664525 + 1013904223
+		body[i] = byte(state >> 24)
+	}
+
+	data := makeSave(t, testSave{version: 0xa9, gameMode: "jh", name: "Player", level: "Callisto", seed: 99, body: body})
+	truncated := data[:len(data)/2]
+
+	if _, err := ParseFull(bytes.NewReader(truncated)); err == nil {
+		t.Fatal("Parsing a truncated save in full succeeded")
+	}
+
+	parsed, err := ParseHeader(bytes.NewReader(truncated))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.PlayerName != "Player" || parsed.Seed != 99 || !parsed.HeaderOnly {
+		t.Fatalf("Got %+v, expected the header fields", parsed)
+	}
+	if _, ok := parsed.Span(SpanBody); ok {
+		t.Fatal("Got a body span from ParseHeader")
+	}
+	if _, err := parsed.Payload(); err == nil {
+		t.Fatal("Writing a save without its body succeeded")
+	}
+
+	if _, err := ParseHeader(bytes.NewReader(makeSave(t, testSave{})[:4])); err == nil {
+		t.Fatal("Parsing the header of a truncated save succeeded")
+	}
+	short := compress(t, makePayload(testSave{version: 0xa9})[:100])
+	if _, err := ParseHeader(bytes.NewReader(short)); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("Got %v, expected %v", err, io.ErrUnexpectedEOF)
+	}
+}
@@ -0,0 +1,115 @@
+package savefile
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// putString stores s in the size byte field at the start of buf, with its length in the last byte of the field.
+// The bytes between the end of s and the length byte are left alone.
+func putString(buf []byte, name string, s []byte, size int) error {
+	if len(s) >= size {
+		return fmt.Errorf("%s is %d bytes long, but must be shorter than %d bytes", name, len(s), size)
+	}
+	if bytes.IndexByte(s, 0) >= 0 {
+		return fmt.Errorf("%s contains a NUL byte", name)
+	}
+
+	copy(buf, s)
+	buf[size-1] = byte(len(s))
+	return nil
+}
+
+// Payload returns the decompressed contents of the save file for s.
+//
+// The Unknown spans are copied to their offsets first, then the known fields are stored over them,
+// so a save returned by Parse gives back exactly the bytes it was parsed from as long as it is unchanged.
+// If a string is shortened, the bytes that it no longer covers are zeroed.
+func (s Save) Payload() ([]byte, error) {
+	if s.HeaderOnly {
+		return nil, errors.New("save was read without its body, so it cannot be written")
+	}
+
+	size := bodyOffset
+	for _, span := range s.Unknown {
+		if span.Offset < 0 {
+			return nil, fmt.Errorf("span %q has negative offset %d", span.Name, span.Offset)
+		}
+		if end := span.Offset + len(span.Data); end > size {
+			size = end
+		}
+	}
+
+	buf := make([]byte, size)
+	for _, span := range s.Unknown {
+		copy(buf[span.Offset:], span.Data)
+	}
+
+	copy(buf, magic)
+	binary.LittleEndian.PutUint32(buf[versionOffset:], s.Version)
+
+	fields := []struct {
+		name   string
+		value  string
+		offset int
+		size   int
+	}{
+		{"GameMode", s.GameMode, gameModeOffset, gameModeSize},
+		{"PlayerName", s.PlayerName, playerNameOffset, playerNameSize},
+		{"CurrentLevel", s.CurrentLevel, currentLevelOffset, currentLevelSize},
+	}
+	for _, f := range fields {
+		// Strings that were not valid UTF-8 are written back as they were read, unless they have been changed
+		raw := []byte(f.value)
+		span, ok := s.Span(f.name)
+		if ok && decodeLossy(span.Data) == f.value {
+			raw = span.Data
+		} else if !utf8.ValidString(f.value) {
+			return nil, fmt.Errorf("%s is not valid UTF-8", f.name)
+		} else if ok {
+			// The old bytes were copied in with the other spans; clear them so that none are left past the new string
+			field := buf[f.offset : f.offset+f.size]
+			for i := range field {
+				field[i] = 0
+			}
+		}
+
+		if err := putString(buf[f.offset:], f.name, raw, f.size); err != nil {
+			return nil, err
+		}
+	}
+
+	binary.LittleEndian.PutUint32(buf[seedOffset:], s.Seed)
+
+	return buf, nil
+}
+
+// Write writes s to w as a zlib-compressed save file.
+//
+// The decompressed contents are those returned by Payload. If they are the same as those of the
+// file that s was parsed from, that file is written back byte for byte. Otherwise the payload is
+// compressed again, and the compressed bytes may differ from what the game would write, since
+// the game may not compress with the same settings.
+func Write(w io.Writer, s Save) error {
+	payload, err := s.Payload()
+	if err != nil {
+		return err
+	}
+
+	if s.compressed != nil && sha256.Sum256(payload) == s.payloadSum {
+		_, err := w.Write(s.compressed)
+		return err
+	}
+
+	z := zlib.NewWriter(w)
+	if _, err := z.Write(payload); err != nil {
+		return err
+	}
+	return z.Close()
+}
@@ -0,0 +1,153 @@
+package savefile
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func decompress(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	z, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := io.ReadAll(z)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	unknown := [32]byte{1, 2, 3, 4}
+	padded := makePayload(testSave{version: 0xa9, gameMode: "jh", name: "Player", level: "Callisto L1", seed: 7, body: []byte{0xff, 0, 1}})
+	copy(padded[currentLevelOffset+len("Callisto L1"):], "garbage")
+
+	payloads := [][]byte{
+		makePayload(testSave{version: 0xa9, gameMode: "jh", name: "Player", level: "Callisto L1", seed: 12345}),
+		makePayload(testSave{version: 0xa2, gameMode: "jh_angel", level: "Europa", headerUnknown: unknown, body: bytes.Repeat([]byte("body"), 1000)}),
+		padded,
+	}
+
+	for _, payload := range payloads {
+		parsed, err := Parse(bytes.NewReader(compress(t, payload)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		buf := &bytes.Buffer{}
+		if err := Write(buf, parsed); err != nil {
+			t.Fatal(err)
+		}
+
+		if written := decompress(t, buf.Bytes()); !bytes.Equal(written, payload) {
+			t.Fatalf("Got %v, expected %v", written, payload)
+		}
+
+		reparsed, err := Parse(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(reparsed, parsed) {
+			t.Fatalf("Got %+v, expected %+v", reparsed, parsed)
+		}
+	}
+}
+
+func TestWriteUnchanged(t *testing.T) {
+	payload := makePayload(testSave{version: 0xa9, gameMode: "jh", name: "Player", level: "Io", seed: 5, body: bytes.Repeat([]byte("body"), 100)})
+
+	// Compress with settings other than Write's own, as the game may
+	buf := &bytes.Buffer{}
+	z, err := zlib.NewWriterLevel(buf, zlib.BestCompression)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z.Write(payload)
+	z.Close()
+	original := buf.Bytes()
+
+	parsed, err := Parse(bytes.NewReader(original))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	written := &bytes.Buffer{}
+	if err := Write(written, parsed); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(written.Bytes(), original) {
+		t.Fatalf("Got %v, expected %v", written.Bytes(), original)
+	}
+
+	// Once something has changed, the payload has to be compressed again
+	parsed.Seed = 6
+	written.Reset()
+	if err := Write(written, parsed); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(written.Bytes(), original) {
+		t.Fatal("Got the original file after changing the seed")
+	}
+	if got := decompress(t, written.Bytes()); binary.LittleEndian.Uint32(got[seedOffset:]) != 6 {
+		t.Fatalf("Got seed %d, expected 6", binary.LittleEndian.Uint32(got[seedOffset:]))
+	}
+}
+
+func TestWriteEdited(t *testing.T) {
+	parsed, err := Parse(bytes.NewReader(makeSave(t, testSave{version: 0xa9, gameMode: "jh", name: "A long player name", level: "Io", seed: 1, body: []byte("body")})))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed.PlayerName = "Short"
+	parsed.Seed = 42
+
+	payload, err := parsed.Payload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := makePayload(testSave{version: 0xa9, gameMode: "jh", name: "Short", level: "Io", seed: 42, body: []byte("body")})
+	if !bytes.Equal(payload, expected) {
+		t.Fatalf("Got %v, expected %v", payload, expected)
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	tests := map[string]Save{
+		"name too long":  {PlayerName: string(bytes.Repeat([]byte("x"), 32))},
+		"invalid utf-8":  {GameMode: "j\xc3"},
+		"too many bytes": {PlayerName: string(bytes.Repeat([]byte("ä"), 16))},
+		"negative span":  {Unknown: []Span{{"body", -1, []byte{1}}}},
+		"nul in a level": {CurrentLevel: "a\x00b"},
+	}
+
+	for name, s := range tests {
+		if err := Write(io.Discard, s); err == nil {
+			t.Fatalf("%s: writing succeeded", name)
+		}
+	}
+}
+
+func TestWriteShortenedInvalidUTF8(t *testing.T) {
+	parsed, err := Parse(bytes.NewReader(makeSave(t, testSave{version: 0xa9, gameMode: "jh", name: "J\xe9r\xf4me", level: "Io", body: []byte("body")})))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed.PlayerName = "Bob"
+
+	payload, err := parsed.Payload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := makePayload(testSave{version: 0xa9, gameMode: "jh", name: "Bob", level: "Io", body: []byte("body")})
+	if !bytes.Equal(payload, expected) {
+		t.Fatalf("Got %v, expected %v", payload, expected)
+	}
+}
@@ -0,0 +1,150 @@
+package savewatch
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sector-f/jhmod/savefile"
+)
+
+// IndexName is the name of the index file in an archive directory.
+const IndexName = "index.jsonl"
+
+// timeFormat is used for the names of archived copies, so that they sort by the time they were archived.
+const timeFormat = "20060102-150405.000"
+
+// Record describes one archived copy of a save file. The index holds one Record per line, as JSON.
+type Record struct {
+	Time         time.Time
+	Source       string
+	File         string
+	Version      uint32
+	GameMode     string
+	PlayerName   string
+	CurrentLevel string
+	Seed         uint32
+	SHA256       string
+}
+
+// ErrUnchanged is returned by Archive.Add when the file is identical to the last copy archived from the same path.
+var ErrUnchanged = errors.New("save is unchanged since it was last archived")
+
+// Archive is a directory of timestamped copies of save files, with an index.
+type Archive struct {
+	Dir  string
+	last map[string]string
+}
+
+// OpenArchive creates dir if needed and returns an Archive for it.
+// The index is read so that unchanged saves are not archived twice.
+func OpenArchive(dir string) (*Archive, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	a := &Archive{Dir: dir, last: map[string]string{}}
+	records, err := a.Records()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		a.last[r.Source] = r.SHA256
+	}
+	return a, nil
+}
+
+// Records returns every record in the index, oldest first.
+func (a *Archive) Records() ([]Record, error) {
+	f, err := os.Open(filepath.Join(a.Dir, IndexName))
+	if errors.Is(err, os.ErrNotExist) {
+		return []Record{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records := []Record{}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		r := Record{}
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", IndexName, line, err)
+		}
+		records = append(records, r)
+	}
+	return records, scanner.Err()
+}
+
+// Add parses the save file at path and, if it is valid, copies it into the archive and adds it to the index.
+// now is used to name the copy.
+func (a *Archive) Add(path string, now time.Time) (Record, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Record{}, err
+	}
+
+	save, err := savefile.Parse(bytes.NewReader(data))
+	if err != nil {
+		return Record{}, err
+	}
+
+	source, err := filepath.Abs(path)
+	if err != nil {
+		return Record{}, err
+	}
+
+	sum := fmt.Sprintf("%x", sha256.Sum256(data))
+	if a.last[source] == sum {
+		return Record{}, ErrUnchanged
+	}
+
+	name := now.Format(timeFormat) + "-" + filepath.Base(path)
+	if err := os.WriteFile(filepath.Join(a.Dir, name), data, 0644); err != nil {
+		return Record{}, err
+	}
+
+	r := Record{
+		Time:         now,
+		Source:       source,
+		File:         name,
+		Version:      save.Version,
+		GameMode:     save.GameMode,
+		PlayerName:   save.PlayerName,
+		CurrentLevel: save.CurrentLevel,
+		Seed:         save.Seed,
+		SHA256:       sum,
+	}
+	if err := a.appendRecord(r); err != nil {
+		return Record{}, err
+	}
+
+	a.last[source] = sum
+	return r, nil
+}
+
+func (a *Archive) appendRecord(r Record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Join(a.Dir, IndexName), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,102 @@
+package savewatch
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestArchiveAdd(t *testing.T) {
+	saves := t.TempDir()
+	path := filepath.Join(saves, "autosave.sav")
+	data := testSave(t, "Io", 42)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "archive")
+	a, err := OpenArchive(dir)
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+
+	now := time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	r, err := a.Add(path, now)
+	if err != nil {
+		t.Fatalf("Failed to add save: %v", err)
+	}
+	if r.File != "20200102-030405.006-autosave.sav" {
+		t.Fatalf("Got %s, expected %s", r.File, "20200102-030405.006-autosave.sav")
+	}
+	if r.GameMode != "jh" || r.PlayerName != "Tester" || r.CurrentLevel != "Io" || r.Seed != 42 {
+		t.Fatalf("Got %+v, expected the fields of the save", r)
+	}
+
+	copied, err := os.ReadFile(filepath.Join(dir, r.File))
+	if err != nil {
+		t.Fatalf("Failed to read copy: %v", err)
+	}
+	if !bytes.Equal(copied, data) {
+		t.Fatalf("Copy differs from the original save")
+	}
+
+	if _, err := a.Add(path, now.Add(time.Second)); !errors.Is(err, ErrUnchanged) {
+		t.Fatalf("Got %v, expected %v", err, ErrUnchanged)
+	}
+
+	// A new Archive reads the index, so it also knows the save is unchanged
+	a, err = OpenArchive(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen archive: %v", err)
+	}
+	if _, err := a.Add(path, now.Add(time.Second)); !errors.Is(err, ErrUnchanged) {
+		t.Fatalf("Got %v, expected %v", err, ErrUnchanged)
+	}
+
+	if err := os.WriteFile(path, testSave(t, "Europa", 42), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Add(path, now.Add(2*time.Second)); err != nil {
+		t.Fatalf("Failed to add changed save: %v", err)
+	}
+
+	records, err := a.Records()
+	if err != nil {
+		t.Fatalf("Failed to read index: %v", err)
+	}
+	levels := []string{}
+	for _, r := range records {
+		levels = append(levels, r.CurrentLevel)
+	}
+	if len(levels) != 2 || levels[0] != "Io" || levels[1] != "Europa" {
+		t.Fatalf("Got %v, expected [Io Europa]", levels)
+	}
+	if !records[0].Time.Equal(now) {
+		t.Fatalf("Got %v, expected %v", records[0].Time, now)
+	}
+}
+
+func TestArchiveAddInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "partial.sav")
+	data := testSave(t, "Io", 1)
+	if err := os.WriteFile(path, data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	a, err := OpenArchive(dir)
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+	if _, err := a.Add(path, time.Now()); err == nil {
+		t.Fatalf("Got no error for a truncated save")
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Fatalf("Got %d files in archive, expected 0", len(entries))
+	}
+}
@@ -0,0 +1,79 @@
+//go:build linux
+
+package savewatch
+
+import (
+	"bytes"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// inotify is a notifier that uses the Linux inotify API.
+type inotify struct {
+	file   *os.File
+	events chan string
+	done   chan struct{}
+}
+
+// startNotifier watches dir for files that are closed after being written, or moved into it.
+func startNotifier(dir string) (notifier, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+
+	if _, err := syscall.InotifyAddWatch(fd, dir, syscall.IN_CLOSE_WRITE|syscall.IN_MOVED_TO); err != nil {
+		syscall.Close(fd)
+		return nil, os.NewSyscallError("inotify_add_watch", err)
+	}
+
+	// A non-blocking descriptor wrapped in an os.File uses the runtime poller,
+	// so closing the file interrupts a pending Read.
+	n := &inotify{
+		file:   os.NewFile(uintptr(fd), "inotify"),
+		events: make(chan string),
+		done:   make(chan struct{}),
+	}
+	go n.read()
+	return n, nil
+}
+
+func (n *inotify) Events() <-chan string {
+	return n.events
+}
+
+func (n *inotify) Close() error {
+	close(n.done)
+	return n.file.Close()
+}
+
+// read sends the name of the file in each event until the inotify file is closed.
+func (n *inotify) read() {
+	defer close(n.events)
+
+	buf := make([]byte, 64*1024)
+	for {
+		count, err := n.file.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= count; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			start := offset + syscall.SizeofInotifyEvent
+			end := start + int(event.Len)
+			offset = end
+			if end > count || event.Len == 0 {
+				continue
+			}
+
+			name := string(bytes.TrimRight(buf[start:end], "\x00"))
+			select {
+			case n.events <- name:
+			case <-n.done:
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package savewatch
+
+// startNotifier always fails with errNoNotify, so the directory is polled instead.
+func startNotifier(dir string) (notifier, error) {
+	return nil, errNoNotify
+}
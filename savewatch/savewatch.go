@@ -0,0 +1,199 @@
+// Package savewatch watches a directory for save files being written and keeps a copy of each one.
+//
+// The game rewrites its save files in place, so the state at each autosave is lost unless it is
+// copied somewhere else as soon as the write finishes. On Linux, inotify is used to find out when
+// a file has been written and closed; elsewhere, or if inotify cannot be used, the directory is polled.
+package savewatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// errNoNotify is returned by startNotifier on systems without a supported notification API.
+var errNoNotify = errors.New("file change notifications are not supported")
+
+// notifier reports the names of files in a directory that have been written or moved into it.
+type notifier interface {
+	Events() <-chan string
+	Close() error
+}
+
+// Watcher watches a directory for save files being written.
+type Watcher struct {
+	// Dir is the directory to watch. Subdirectories are not watched.
+	Dir string
+	// Pattern is matched against file names with filepath.Match. An empty pattern matches every file.
+	Pattern string
+	// Settle is how long a file must go without changing before it is considered complete.
+	Settle time.Duration
+	// Poll forces polling, even if change notifications are available.
+	Poll bool
+	// Interval is how often the directory is polled.
+	Interval time.Duration
+	// Warn, if set, is called with the reason when change notifications cannot be used and the
+	// directory is polled instead.
+	Warn func(err error)
+}
+
+// NewWatcher returns a Watcher for dir with default settings.
+func NewWatcher(dir string) *Watcher {
+	return &Watcher{
+		Dir:      dir,
+		Pattern:  "*.sav",
+		Settle:   500 * time.Millisecond,
+		Interval: time.Second,
+	}
+}
+
+func (w *Watcher) matches(name string) bool {
+	if w.Pattern == "" {
+		return true
+	}
+	matched, _ := filepath.Match(w.Pattern, name)
+	return matched
+}
+
+// Run watches the directory until ctx is done, calling handle with the path of each file that has
+// been written once it has settled. Files which already exist when Run starts are not reported
+// until they are written again. handle is called from the goroutine that called Run.
+func (w *Watcher) Run(ctx context.Context, handle func(path string)) error {
+	if _, err := os.Stat(w.Dir); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	changes := make(chan string)
+	settle := w.Settle
+
+	var n notifier
+	if !w.Poll {
+		var err error
+		n, err = startNotifier(w.Dir)
+		// Notifications can fail to start even where they are supported, such as when the inotify
+		// watch limit is reached, so fall back to polling for any error
+		if err != nil && !errors.Is(err, errNoNotify) && w.Warn != nil {
+			w.Warn(fmt.Errorf("falling back to polling: %w", err))
+		}
+	}
+
+	if n != nil {
+		defer n.Close()
+		go forward(ctx, n.Events(), changes)
+	} else {
+		// A file that is still being written might not change between two polls, so wait for a couple
+		if settle < 2*w.Interval {
+			settle = 2 * w.Interval
+		}
+		go w.poll(ctx, changes)
+	}
+
+	tick := settle / 4
+	if tick <= 0 {
+		tick = time.Millisecond
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	pending := map[string]time.Time{}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case name := <-changes:
+			if w.matches(name) {
+				pending[name] = time.Now()
+			}
+
+		case now := <-ticker.C:
+			for name, changed := range pending {
+				if now.Sub(changed) >= settle {
+					delete(pending, name)
+					handle(filepath.Join(w.Dir, name))
+				}
+			}
+		}
+	}
+}
+
+// forward copies names from events to changes until ctx is done or events is closed.
+func forward(ctx context.Context, events <-chan string, changes chan<- string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case name, ok := <-events:
+			if !ok {
+				return
+			}
+			select {
+			case changes <- name:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// fileState is what poll uses to tell whether a file has changed.
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// scan returns the state of every file in dir.
+func scan(dir string) map[string]fileState {
+	states := map[string]fileState{}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return states
+	}
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			states[e.Name()] = fileState{info.Size(), info.ModTime()}
+		}
+	}
+	return states
+}
+
+// poll sends the name of each file in the directory whose size or modification time changes, until ctx is done.
+func (w *Watcher) poll(ctx context.Context, changes chan<- string) {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := scan(w.Dir)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := scan(w.Dir)
+		for name, state := range current {
+			if prev, seen := last[name]; seen && prev == state {
+				continue
+			}
+			select {
+			case changes <- name:
+			case <-ctx.Done():
+				return
+			}
+		}
+		last = current
+	}
+}
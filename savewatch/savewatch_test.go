@@ -0,0 +1,110 @@
+package savewatch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sector-f/jhmod/savefile"
+)
+
+func testSave(t *testing.T, level string, seed uint32) []byte {
+	buf := &bytes.Buffer{}
+	save := savefile.Save{Version: 0xa9, GameMode: "jh", PlayerName: "Tester", CurrentLevel: level, Seed: seed}
+	if err := savefile.Write(buf, save); err != nil {
+		t.Fatalf("Failed to write save: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// watch runs w in the background and returns a channel of the paths it reports.
+func watch(t *testing.T, w *Watcher) <-chan string {
+	ctx, cancel := context.WithCancel(context.Background())
+	found := make(chan string, 16)
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	go func() {
+		defer close(done)
+		if err := w.Run(ctx, func(path string) { found <- path }); err != nil {
+			t.Errorf("Run failed: %v", err)
+		}
+	}()
+	return found
+}
+
+func expectPath(t *testing.T, found <-chan string, expected string) {
+	select {
+	case got := <-found:
+		if got != expected {
+			t.Fatalf("Got %s, expected %s", got, expected)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for %s", expected)
+	}
+}
+
+func testWatcher(t *testing.T, poll bool) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "old.sav")
+	if err := os.WriteFile(existing, testSave(t, "Io", 1), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := NewWatcher(dir)
+	w.Poll = poll
+	w.Settle = 20 * time.Millisecond
+	w.Interval = 20 * time.Millisecond
+	found := watch(t, w)
+
+	// Give the watcher time to start before writing
+	time.Sleep(100 * time.Millisecond)
+
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a save"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "autosave.sav")
+	if err := os.WriteFile(path, testSave(t, "Io", 1), 0644); err != nil {
+		t.Fatal(err)
+	}
+	expectPath(t, found, path)
+
+	// A save written to a temporary file and renamed into place is also reported
+	tmp := filepath.Join(dir, "autosave.tmp")
+	if err := os.WriteFile(tmp, testSave(t, "Europa", 2), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, existing); err != nil {
+		t.Fatal(err)
+	}
+	expectPath(t, found, existing)
+}
+
+func TestWatcherPoll(t *testing.T) {
+	testWatcher(t, true)
+}
+
+func TestWatcherNotify(t *testing.T) {
+	if n, err := startNotifier(t.TempDir()); errors.Is(err, errNoNotify) {
+		t.Skip("Change notifications are not supported")
+	} else if err != nil {
+		t.Fatalf("Failed to start notifier: %v", err)
+	} else {
+		n.Close()
+	}
+	testWatcher(t, false)
+}
+
+func TestWatcherMissingDir(t *testing.T) {
+	w := NewWatcher(filepath.Join(t.TempDir(), "missing"))
+	if err := w.Run(context.Background(), func(string) {}); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Got %v, expected %v", err, os.ErrNotExist)
+	}
+}
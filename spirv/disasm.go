@@ -0,0 +1,111 @@
+package spirv
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Disassemble writes a textual listing of m to w, in a format similar to that of spirv-dis.
+// IDs are written as %N, where N is the numeric ID.
+func Disassemble(w io.Writer, m Module) error {
+	header := fmt.Sprintf("; SPIR-V\n; Version: %s\n; Generator: 0x%08x\n; Bound: %d\n; Schema: %d\n",
+		m.VersionString(), m.Generator, m.Bound, m.Schema)
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+
+	for _, inst := range m.Instructions {
+		if _, err := fmt.Fprintln(w, FormatInstruction(inst)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FormatInstruction returns a single line of disassembly for inst.
+func FormatInstruction(inst Instruction) string {
+	info := inst.Opcode.info()
+
+	prefix := ""
+	if result, ok := inst.Result(); ok {
+		prefix = fmt.Sprintf("%%%d = ", result)
+	}
+
+	parts := []string{info.name}
+	if resultType, ok := inst.ResultType(); ok {
+		parts = append(parts, fmt.Sprintf("%%%d", resultType))
+	}
+
+	args := inst.Args()
+	kinds := info.operands
+	if kinds == "" {
+		kinds = "l"
+	}
+
+	// The literal after a BuiltIn decoration is itself an enumerant
+	builtIn := false
+
+	for i, pos := 0, 0; i < len(args); pos++ {
+		kind := kinds[len(kinds)-1]
+		if pos < len(kinds) {
+			kind = kinds[pos]
+		}
+
+		if kind == 's' {
+			s, n := DecodeString(args[i:])
+			parts = append(parts, strconv.Quote(s))
+			i += n
+			continue
+		}
+
+		if builtIn && kind == 'l' {
+			kind = 'b'
+		}
+		builtIn = kind == 'd' && DecorationKind(args[i]) == DecorationBuiltIn
+
+		parts = append(parts, formatOperand(kind, args[i]))
+		i++
+	}
+
+	return fmt.Sprintf("%15s%s", prefix, strings.Join(parts, " "))
+}
+
+// formatOperand formats a single-word operand. kind is one of:
+//
+//	i  an ID
+//	l  a literal number
+//	b  a BuiltIn
+//	x  an ExecutionModel
+//	c  a StorageClass
+//	d  a DecorationKind
+//	A  an addressing model
+//	M  a memory model
+//	E  an execution mode
+//	C  a capability
+func formatOperand(kind byte, v uint32) string {
+	switch kind {
+	case 'i':
+		return fmt.Sprintf("%%%d", v)
+	case 'b':
+		return builtIns.name(v)
+	case 'x':
+		return executionModels.name(v)
+	case 'c':
+		return storageClasses.name(v)
+	case 'd':
+		return decorations.name(v)
+	case 'A':
+		return addressingModels.name(v)
+	case 'M':
+		return memoryModels.name(v)
+	case 'E':
+		return executionModes.name(v)
+	case 'C':
+		return capabilities.name(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,148 @@
+package spirv
+
+import "sort"
+
+// EntryPoint is a function that the module exports as a shader stage.
+type EntryPoint struct {
+	Model     ExecutionModel
+	Function  uint32   // ID of the entry point's function
+	Name      string   // Name that the entry point is exported as
+	Interface []uint32 // IDs of the global variables that the entry point uses
+}
+
+// EntryPoints returns the module's entry points, in the order they are declared.
+func (m Module) EntryPoints() []EntryPoint {
+	points := []EntryPoint{}
+	for _, inst := range m.Instructions {
+		if inst.Opcode != OpEntryPoint || len(inst.Operands) < 3 {
+			continue
+		}
+
+		name, n := DecodeString(inst.Operands[2:])
+		points = append(points, EntryPoint{
+			Model:     ExecutionModel(inst.Operands[0]),
+			Function:  inst.Operands[1],
+			Name:      name,
+			Interface: inst.Operands[2+n:],
+		})
+	}
+	return points
+}
+
+// Names returns the debug names (from OpName) of the module's IDs.
+// Modules which have been stripped of debug information have no names.
+func (m Module) Names() map[uint32]string {
+	names := map[uint32]string{}
+	for _, inst := range m.Instructions {
+		if inst.Opcode == OpName && len(inst.Operands) >= 2 {
+			names[inst.Operands[0]], _ = DecodeString(inst.Operands[1:])
+		}
+	}
+	return names
+}
+
+// Decoration is a decoration applied to an ID, or to a member of a structure type.
+type Decoration struct {
+	Target   uint32
+	Member   int // Index of the structure member, or -1 if the decoration applies to Target itself
+	Kind     DecorationKind
+	Operands []uint32 // Extra operands, such as the number for Location and Binding decorations
+}
+
+// Value returns the first extra operand of d.
+func (d Decoration) Value() (uint32, bool) {
+	if len(d.Operands) == 0 {
+		return 0, false
+	}
+	return d.Operands[0], true
+}
+
+// Decorations returns every decoration in the module, in the order they are declared.
+func (m Module) Decorations() []Decoration {
+	decs := []Decoration{}
+	for _, inst := range m.Instructions {
+		switch {
+		case inst.Opcode == OpDecorate && len(inst.Operands) >= 2:
+			decs = append(decs, Decoration{
+				Target:   inst.Operands[0],
+				Member:   -1,
+				Kind:     DecorationKind(inst.Operands[1]),
+				Operands: inst.Operands[2:],
+			})
+		case inst.Opcode == OpMemberDecorate && len(inst.Operands) >= 3:
+			decs = append(decs, Decoration{
+				Target:   inst.Operands[0],
+				Member:   int(inst.Operands[1]),
+				Kind:     DecorationKind(inst.Operands[2]),
+				Operands: inst.Operands[3:],
+			})
+		}
+	}
+	return decs
+}
+
+// Variable is a global variable, such as a shader input or a uniform buffer.
+type Variable struct {
+	ID           uint32
+	Name         string // Name of the variable, or of its type if the variable itself is unnamed
+	StorageClass StorageClass
+	Decorations  []Decoration
+}
+
+// Decoration returns the value of the first decoration of the given kind on v.
+func (v Variable) Decoration(kind DecorationKind) (uint32, bool) {
+	for _, d := range v.Decorations {
+		if d.Kind == kind {
+			return d.Value()
+		}
+	}
+	return 0, false
+}
+
+// Variables returns the module's global variables, sorted by ID.
+func (m Module) Variables() []Variable {
+	names := m.Names()
+
+	decs := map[uint32][]Decoration{}
+	for _, d := range m.Decorations() {
+		if d.Member < 0 {
+			decs[d.Target] = append(decs[d.Target], d)
+		}
+	}
+
+	// pointees maps pointer types to the type that they point to
+	pointees := map[uint32]uint32{}
+
+	vars := []Variable{}
+	for _, inst := range m.Instructions {
+		switch inst.Opcode {
+		case OpTypePointer:
+			if len(inst.Operands) >= 3 {
+				pointees[inst.Operands[0]] = inst.Operands[2]
+			}
+
+		case OpVariable:
+			if len(inst.Operands) < 3 {
+				continue
+			}
+			v := Variable{
+				ID:           inst.Operands[1],
+				StorageClass: StorageClass(inst.Operands[2]),
+				Decorations:  decs[inst.Operands[1]],
+			}
+			if v.StorageClass == StorageClassFunction {
+				continue
+			}
+
+			v.Name = names[v.ID]
+			if v.Name == "" {
+				v.Name = names[pointees[inst.Operands[0]]]
+			}
+
+			vars = append(vars, v)
+		}
+	}
+
+	sort.Slice(vars, func(i, j int) bool { return vars[i].ID < vars[j].ID })
+	return vars
+}
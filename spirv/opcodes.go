@@ -0,0 +1,444 @@
+package spirv
+
+import "fmt"
+
+// Opcode identifies the operation performed by an instruction.
+type Opcode uint16
+
+// Opcodes used by this package.
+const (
+	OpName           Opcode = 5
+	OpMemberName     Opcode = 6
+	OpExtInstImport  Opcode = 11
+	OpEntryPoint     Opcode = 15
+	OpTypePointer    Opcode = 32
+	OpVariable       Opcode = 59
+	OpDecorate       Opcode = 71
+	OpMemberDecorate Opcode = 72
+)
+
+// opInfo describes the layout of an instruction's operands.
+type opInfo struct {
+	name      string
+	hasType   bool // The first operand is the ID of the result's type
+	hasResult bool // The next operand is the ID of the result
+
+	// operands describes the remaining operands, one character per operand.
+	// The last character applies to any further operands. See formatOperand for the meaning of each character.
+	operands string
+}
+
+// opcodes lists the instructions that are commonly found in shaders.
+// Instructions that are not listed are disassembled with literal operands.
+var opcodes = map[Opcode]opInfo{
+	0:   {"OpNop", false, false, ""},
+	1:   {"OpUndef", true, true, ""},
+	2:   {"OpSourceContinued", false, false, "s"},
+	3:   {"OpSource", false, false, "llis"},
+	4:   {"OpSourceExtension", false, false, "s"},
+	5:   {"OpName", false, false, "is"},
+	6:   {"OpMemberName", false, false, "ils"},
+	7:   {"OpString", false, true, "s"},
+	8:   {"OpLine", false, false, "ill"},
+	10:  {"OpExtension", false, false, "s"},
+	11:  {"OpExtInstImport", false, true, "s"},
+	12:  {"OpExtInst", true, true, "ili"},
+	14:  {"OpMemoryModel", false, false, "AM"},
+	15:  {"OpEntryPoint", false, false, "xisi"},
+	16:  {"OpExecutionMode", false, false, "iEl"},
+	17:  {"OpCapability", false, false, "C"},
+	19:  {"OpTypeVoid", false, true, ""},
+	20:  {"OpTypeBool", false, true, ""},
+	21:  {"OpTypeInt", false, true, "ll"},
+	22:  {"OpTypeFloat", false, true, "l"},
+	23:  {"OpTypeVector", false, true, "il"},
+	24:  {"OpTypeMatrix", false, true, "il"},
+	25:  {"OpTypeImage", false, true, "il"},
+	26:  {"OpTypeSampler", false, true, ""},
+	27:  {"OpTypeSampledImage", false, true, "i"},
+	28:  {"OpTypeArray", false, true, "ii"},
+	29:  {"OpTypeRuntimeArray", false, true, "i"},
+	30:  {"OpTypeStruct", false, true, "i"},
+	31:  {"OpTypeOpaque", false, true, "s"},
+	32:  {"OpTypePointer", false, true, "ci"},
+	33:  {"OpTypeFunction", false, true, "i"},
+	41:  {"OpConstantTrue", true, true, ""},
+	42:  {"OpConstantFalse", true, true, ""},
+	43:  {"OpConstant", true, true, "l"},
+	44:  {"OpConstantComposite", true, true, "i"},
+	46:  {"OpConstantNull", true, true, ""},
+	48:  {"OpSpecConstantTrue", true, true, ""},
+	49:  {"OpSpecConstantFalse", true, true, ""},
+	50:  {"OpSpecConstant", true, true, "l"},
+	51:  {"OpSpecConstantComposite", true, true, "i"},
+	52:  {"OpSpecConstantOp", true, true, "li"},
+	54:  {"OpFunction", true, true, "li"},
+	55:  {"OpFunctionParameter", true, true, ""},
+	56:  {"OpFunctionEnd", false, false, ""},
+	57:  {"OpFunctionCall", true, true, "i"},
+	59:  {"OpVariable", true, true, "ci"},
+	61:  {"OpLoad", true, true, "il"},
+	62:  {"OpStore", false, false, "iil"},
+	63:  {"OpCopyMemory", false, false, "iil"},
+	65:  {"OpAccessChain", true, true, "i"},
+	66:  {"OpInBoundsAccessChain", true, true, "i"},
+	68:  {"OpArrayLength", true, true, "il"},
+	71:  {"OpDecorate", false, false, "idl"},
+	72:  {"OpMemberDecorate", false, false, "ildl"},
+	73:  {"OpDecorationGroup", false, true, ""},
+	74:  {"OpGroupDecorate", false, false, "i"},
+	77:  {"OpVectorExtractDynamic", true, true, "i"},
+	78:  {"OpVectorInsertDynamic", true, true, "i"},
+	79:  {"OpVectorShuffle", true, true, "iil"},
+	80:  {"OpCompositeConstruct", true, true, "i"},
+	81:  {"OpCompositeExtract", true, true, "il"},
+	82:  {"OpCompositeInsert", true, true, "iil"},
+	83:  {"OpCopyObject", true, true, "i"},
+	84:  {"OpTranspose", true, true, "i"},
+	86:  {"OpSampledImage", true, true, "i"},
+	87:  {"OpImageSampleImplicitLod", true, true, "iili"},
+	88:  {"OpImageSampleExplicitLod", true, true, "iili"},
+	89:  {"OpImageSampleDrefImplicitLod", true, true, "iiili"},
+	90:  {"OpImageSampleDrefExplicitLod", true, true, "iiili"},
+	95:  {"OpImageFetch", true, true, "iili"},
+	96:  {"OpImageGather", true, true, "iiili"},
+	98:  {"OpImageRead", true, true, "iili"},
+	99:  {"OpImageWrite", false, false, "iiili"},
+	100: {"OpImage", true, true, "i"},
+	103: {"OpImageQuerySizeLod", true, true, "i"},
+	104: {"OpImageQuerySize", true, true, "i"},
+	109: {"OpConvertFToU", true, true, "i"},
+	110: {"OpConvertFToS", true, true, "i"},
+	111: {"OpConvertSToF", true, true, "i"},
+	112: {"OpConvertUToF", true, true, "i"},
+	113: {"OpUConvert", true, true, "i"},
+	114: {"OpSConvert", true, true, "i"},
+	115: {"OpFConvert", true, true, "i"},
+	124: {"OpBitcast", true, true, "i"},
+	126: {"OpSNegate", true, true, "i"},
+	127: {"OpFNegate", true, true, "i"},
+	128: {"OpIAdd", true, true, "i"},
+	129: {"OpFAdd", true, true, "i"},
+	130: {"OpISub", true, true, "i"},
+	131: {"OpFSub", true, true, "i"},
+	132: {"OpIMul", true, true, "i"},
+	133: {"OpFMul", true, true, "i"},
+	134: {"OpUDiv", true, true, "i"},
+	135: {"OpSDiv", true, true, "i"},
+	136: {"OpFDiv", true, true, "i"},
+	137: {"OpUMod", true, true, "i"},
+	138: {"OpSRem", true, true, "i"},
+	139: {"OpSMod", true, true, "i"},
+	140: {"OpFRem", true, true, "i"},
+	141: {"OpFMod", true, true, "i"},
+	142: {"OpVectorTimesScalar", true, true, "i"},
+	143: {"OpMatrixTimesScalar", true, true, "i"},
+	144: {"OpVectorTimesMatrix", true, true, "i"},
+	145: {"OpMatrixTimesVector", true, true, "i"},
+	146: {"OpMatrixTimesMatrix", true, true, "i"},
+	147: {"OpOuterProduct", true, true, "i"},
+	148: {"OpDot", true, true, "i"},
+	154: {"OpAny", true, true, "i"},
+	155: {"OpAll", true, true, "i"},
+	156: {"OpIsNan", true, true, "i"},
+	157: {"OpIsInf", true, true, "i"},
+	164: {"OpLogicalEqual", true, true, "i"},
+	165: {"OpLogicalNotEqual", true, true, "i"},
+	166: {"OpLogicalOr", true, true, "i"},
+	167: {"OpLogicalAnd", true, true, "i"},
+	168: {"OpLogicalNot", true, true, "i"},
+	169: {"OpSelect", true, true, "i"},
+	170: {"OpIEqual", true, true, "i"},
+	171: {"OpINotEqual", true, true, "i"},
+	172: {"OpUGreaterThan", true, true, "i"},
+	173: {"OpSGreaterThan", true, true, "i"},
+	174: {"OpUGreaterThanEqual", true, true, "i"},
+	175: {"OpSGreaterThanEqual", true, true, "i"},
+	176: {"OpULessThan", true, true, "i"},
+	177: {"OpSLessThan", true, true, "i"},
+	178: {"OpULessThanEqual", true, true, "i"},
+	179: {"OpSLessThanEqual", true, true, "i"},
+	180: {"OpFOrdEqual", true, true, "i"},
+	181: {"OpFUnordEqual", true, true, "i"},
+	182: {"OpFOrdNotEqual", true, true, "i"},
+	183: {"OpFUnordNotEqual", true, true, "i"},
+	184: {"OpFOrdLessThan", true, true, "i"},
+	185: {"OpFUnordLessThan", true, true, "i"},
+	186: {"OpFOrdGreaterThan", true, true, "i"},
+	187: {"OpFUnordGreaterThan", true, true, "i"},
+	188: {"OpFOrdLessThanEqual", true, true, "i"},
+	189: {"OpFUnordLessThanEqual", true, true, "i"},
+	190: {"OpFOrdGreaterThanEqual", true, true, "i"},
+	191: {"OpFUnordGreaterThanEqual", true, true, "i"},
+	194: {"OpShiftRightLogical", true, true, "i"},
+	195: {"OpShiftRightArithmetic", true, true, "i"},
+	196: {"OpShiftLeftLogical", true, true, "i"},
+	197: {"OpBitwiseOr", true, true, "i"},
+	198: {"OpBitwiseXor", true, true, "i"},
+	199: {"OpBitwiseAnd", true, true, "i"},
+	200: {"OpNot", true, true, "i"},
+	207: {"OpDPdx", true, true, "i"},
+	208: {"OpDPdy", true, true, "i"},
+	209: {"OpFwidth", true, true, "i"},
+	245: {"OpPhi", true, true, "i"},
+	246: {"OpLoopMerge", false, false, "iil"},
+	247: {"OpSelectionMerge", false, false, "il"},
+	248: {"OpLabel", false, true, ""},
+	249: {"OpBranch", false, false, "i"},
+	250: {"OpBranchConditional", false, false, "iiil"},
+	251: {"OpSwitch", false, false, "iil"},
+	252: {"OpKill", false, false, ""},
+	253: {"OpReturn", false, false, ""},
+	254: {"OpReturnValue", false, false, "i"},
+	255: {"OpUnreachable", false, false, ""},
+	317: {"OpNoLine", false, false, ""},
+	331: {"OpModuleProcessed", false, false, "s"},
+	332: {"OpExecutionModeId", false, false, "iEi"},
+	333: {"OpDecorateId", false, false, "idi"},
+}
+
+func (op Opcode) info() opInfo {
+	if info, exists := opcodes[op]; exists {
+		return info
+	}
+	return opInfo{name: fmt.Sprintf("Op%d", uint16(op)), operands: "l"}
+}
+
+func (op Opcode) String() string {
+	return op.info().name
+}
+
+// enum maps the values of a SPIR-V enumeration to their names.
+type enum map[uint32]string
+
+// name returns the name of v, or v as a number if it has no name.
+func (e enum) name(v uint32) string {
+	if n, exists := e[v]; exists {
+		return n
+	}
+	return fmt.Sprint(v)
+}
+
+// ExecutionModel is the type of shader an entry point is for.
+type ExecutionModel uint32
+
+var executionModels = enum{
+	0:    "Vertex",
+	1:    "TessellationControl",
+	2:    "TessellationEvaluation",
+	3:    "Geometry",
+	4:    "Fragment",
+	5:    "GLCompute",
+	6:    "Kernel",
+	5267: "TaskNV",
+	5268: "MeshNV",
+}
+
+func (m ExecutionModel) String() string {
+	return executionModels.name(uint32(m))
+}
+
+// StorageClass describes where a variable is stored.
+type StorageClass uint32
+
+// Storage classes used by this package.
+const (
+	StorageClassUniformConstant StorageClass = 0
+	StorageClassInput           StorageClass = 1
+	StorageClassUniform         StorageClass = 2
+	StorageClassOutput          StorageClass = 3
+	StorageClassFunction        StorageClass = 7
+	StorageClassPushConstant    StorageClass = 9
+	StorageClassStorageBuffer   StorageClass = 12
+)
+
+var storageClasses = enum{
+	0:  "UniformConstant",
+	1:  "Input",
+	2:  "Uniform",
+	3:  "Output",
+	4:  "Workgroup",
+	5:  "CrossWorkgroup",
+	6:  "Private",
+	7:  "Function",
+	8:  "Generic",
+	9:  "PushConstant",
+	10: "AtomicCounter",
+	11: "Image",
+	12: "StorageBuffer",
+}
+
+func (c StorageClass) String() string {
+	return storageClasses.name(uint32(c))
+}
+
+// DecorationKind is the kind of a decoration applied to an ID.
+type DecorationKind uint32
+
+// Decorations used by this package.
+const (
+	DecorationBuiltIn       DecorationKind = 11
+	DecorationLocation      DecorationKind = 30
+	DecorationBinding       DecorationKind = 33
+	DecorationDescriptorSet DecorationKind = 34
+)
+
+var decorations = enum{
+	0:  "RelaxedPrecision",
+	1:  "SpecId",
+	2:  "Block",
+	3:  "BufferBlock",
+	4:  "RowMajor",
+	5:  "ColMajor",
+	6:  "ArrayStride",
+	7:  "MatrixStride",
+	8:  "GLSLShared",
+	9:  "GLSLPacked",
+	10: "CPacked",
+	11: "BuiltIn",
+	13: "NoPerspective",
+	14: "Flat",
+	15: "Patch",
+	16: "Centroid",
+	17: "Sample",
+	18: "Invariant",
+	19: "Restrict",
+	20: "Aliased",
+	21: "Volatile",
+	22: "Constant",
+	23: "Coherent",
+	24: "NonWritable",
+	25: "NonReadable",
+	26: "Uniform",
+	28: "SaturatedConversion",
+	29: "Stream",
+	30: "Location",
+	31: "Component",
+	32: "Index",
+	33: "Binding",
+	34: "DescriptorSet",
+	35: "Offset",
+	36: "XfbBuffer",
+	37: "XfbStride",
+	38: "FuncParamAttr",
+	39: "FPRoundingMode",
+	40: "FPFastMathMode",
+	41: "LinkageAttributes",
+	42: "NoContraction",
+	43: "InputAttachmentIndex",
+	44: "Alignment",
+}
+
+func (d DecorationKind) String() string {
+	return decorations.name(uint32(d))
+}
+
+// BuiltIn identifies a built-in variable, such as the position output of a vertex shader.
+type BuiltIn uint32
+
+var builtIns = enum{
+	0:  "Position",
+	1:  "PointSize",
+	3:  "ClipDistance",
+	4:  "CullDistance",
+	5:  "VertexId",
+	6:  "InstanceId",
+	7:  "PrimitiveId",
+	8:  "InvocationId",
+	9:  "Layer",
+	10: "ViewportIndex",
+	11: "TessLevelOuter",
+	12: "TessLevelInner",
+	13: "TessCoord",
+	14: "PatchVertices",
+	15: "FragCoord",
+	16: "PointCoord",
+	17: "FrontFacing",
+	18: "SampleId",
+	19: "SamplePosition",
+	20: "SampleMask",
+	22: "FragDepth",
+	23: "HelperInvocation",
+	24: "NumWorkgroups",
+	25: "WorkgroupSize",
+	26: "WorkgroupId",
+	27: "LocalInvocationId",
+	28: "GlobalInvocationId",
+	29: "LocalInvocationIndex",
+	42: "VertexIndex",
+	43: "InstanceIndex",
+}
+
+func (b BuiltIn) String() string {
+	return builtIns.name(uint32(b))
+}
+
+var addressingModels = enum{
+	0:    "Logical",
+	1:    "Physical32",
+	2:    "Physical64",
+	5348: "PhysicalStorageBuffer64",
+}
+
+var memoryModels = enum{
+	0: "Simple",
+	1: "GLSL450",
+	2: "OpenCL",
+	3: "Vulkan",
+}
+
+var executionModes = enum{
+	0:  "Invocations",
+	1:  "SpacingEqual",
+	2:  "SpacingFractionalEven",
+	3:  "SpacingFractionalOdd",
+	4:  "VertexOrderCw",
+	5:  "VertexOrderCcw",
+	6:  "PixelCenterInteger",
+	7:  "OriginUpperLeft",
+	8:  "OriginLowerLeft",
+	9:  "EarlyFragmentTests",
+	10: "PointMode",
+	11: "Xfb",
+	12: "DepthReplacing",
+	14: "DepthGreater",
+	15: "DepthLess",
+	16: "DepthUnchanged",
+	17: "LocalSize",
+	18: "LocalSizeHint",
+	19: "InputPoints",
+	20: "InputLines",
+	21: "InputLinesAdjacency",
+	22: "Triangles",
+	23: "InputTrianglesAdjacency",
+	24: "Quads",
+	25: "Isolines",
+	26: "OutputVertices",
+	27: "OutputPoints",
+	28: "OutputLineStrip",
+	29: "OutputTriangleStrip",
+}
+
+var capabilities = enum{
+	0:  "Matrix",
+	1:  "Shader",
+	2:  "Geometry",
+	3:  "Tessellation",
+	4:  "Addresses",
+	5:  "Linkage",
+	6:  "Kernel",
+	9:  "Float16",
+	10: "Float64",
+	11: "Int64",
+	22: "Int16",
+	32: "ClipDistance",
+	33: "CullDistance",
+	39: "Int8",
+	40: "InputAttachment",
+	43: "SampledBuffer",
+	44: "ImageBuffer",
+	49: "StorageImageExtendedFormats",
+	50: "ImageQuery",
+	51: "DerivativeControl",
+	56: "StorageImageWriteWithoutFormat",
+}
@@ -0,0 +1,165 @@
+// Package spirv implements a parser for SPIR-V shader modules.
+//
+// A module is a stream of 32-bit words. The format is described below; see the SPIR-V specification
+// (https://registry.khronos.org/SPIR-V/) for details.
+//
+//  1. Magic number 0x07230203, which also determines the byte order of the module (1 word)
+//  2. Version, generator magic number, ID bound and schema (4 words)
+//  3. Instructions. The first word of each instruction holds its length in words in its upper 16 bits
+//     and its opcode in its lower 16 bits. The remaining words are the instruction's operands.
+package spirv
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Magic is the first word of every SPIR-V module.
+const Magic uint32 = 0x07230203
+
+const headerWords = 5 // Number of words in the module header, including Magic
+
+var ErrNoMagicFound error = errors.New("spir-v magic number not found")
+
+// Header is the header of a module.
+type Header struct {
+	Version   uint32 // Version of SPIR-V used by the module; see VersionString
+	Generator uint32 // Identifies the tool that generated the module
+	Bound     uint32 // All IDs in the module are less than Bound
+	Schema    uint32
+}
+
+// VersionString returns the module's SPIR-V version as "major.minor".
+func (h Header) VersionString() string {
+	return fmt.Sprintf("%d.%d", (h.Version>>16)&0xff, (h.Version>>8)&0xff)
+}
+
+// Instruction is a single instruction in a module.
+type Instruction struct {
+	Offset   int    // Offset (in words) of the instruction from the start of the module
+	Opcode   Opcode // Operation performed by the instruction
+	Operands []uint32
+}
+
+// Module is a parsed SPIR-V module.
+type Module struct {
+	Header
+	ByteOrder    binary.ByteOrder // Byte order that the module was stored in
+	Instructions []Instruction
+}
+
+// Parse reads a SPIR-V module from r.
+func Parse(r io.Reader) (Module, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Module{}, err
+	}
+
+	if len(data)%4 != 0 {
+		return Module{}, fmt.Errorf("module length %d is not a multiple of 4", len(data))
+	}
+	if len(data) < headerWords*4 {
+		return Module{}, ErrNoMagicFound
+	}
+
+	var order binary.ByteOrder
+	switch {
+	case binary.LittleEndian.Uint32(data) == Magic:
+		order = binary.LittleEndian
+	case binary.BigEndian.Uint32(data) == Magic:
+		order = binary.BigEndian
+	default:
+		return Module{}, ErrNoMagicFound
+	}
+
+	words := make([]uint32, len(data)/4)
+	for i := range words {
+		words[i] = order.Uint32(data[i*4:])
+	}
+
+	m := Module{
+		Header: Header{
+			Version:   words[1],
+			Generator: words[2],
+			Bound:     words[3],
+			Schema:    words[4],
+		},
+		ByteOrder: order,
+	}
+
+	for i := headerWords; i < len(words); {
+		count := int(words[i] >> 16)
+		if count == 0 {
+			return Module{}, fmt.Errorf("instruction at word %d has zero length", i)
+		}
+		if i+count > len(words) {
+			return Module{}, fmt.Errorf("instruction at word %d extends past the end of the module", i)
+		}
+
+		m.Instructions = append(m.Instructions, Instruction{
+			Offset:   i,
+			Opcode:   Opcode(words[i] & 0xffff),
+			Operands: words[i+1 : i+count],
+		})
+		i += count
+	}
+
+	return m, nil
+}
+
+// ResultType returns the ID of the instruction's result type, if it has one.
+func (inst Instruction) ResultType() (uint32, bool) {
+	info := inst.Opcode.info()
+	if !info.hasType || len(inst.Operands) < 1 {
+		return 0, false
+	}
+	return inst.Operands[0], true
+}
+
+// Result returns the ID of the instruction's result, if it has one.
+func (inst Instruction) Result() (uint32, bool) {
+	info := inst.Opcode.info()
+	idx := 0
+	if info.hasType {
+		idx++
+	}
+	if !info.hasResult || len(inst.Operands) <= idx {
+		return 0, false
+	}
+	return inst.Operands[idx], true
+}
+
+// Args returns the instruction's operands after its result type and result ID.
+func (inst Instruction) Args() []uint32 {
+	info := inst.Opcode.info()
+	skip := 0
+	if info.hasType {
+		skip++
+	}
+	if info.hasResult {
+		skip++
+	}
+	if skip > len(inst.Operands) {
+		return nil
+	}
+	return inst.Operands[skip:]
+}
+
+// DecodeString decodes a literal string starting at words[0].
+// Strings are nul-terminated UTF-8 packed into words in little endian order, regardless of the module's byte order.
+// The number of words occupied by the string is returned along with it.
+func DecodeString(words []uint32) (string, int) {
+	buf := []byte{}
+	for i, w := range words {
+		for shift := 0; shift < 32; shift += 8 {
+			b := byte(w >> shift)
+			if b == 0 {
+				return string(buf), i + 1
+			}
+			buf = append(buf, b)
+		}
+	}
+	return string(buf), len(words)
+}
@@ -0,0 +1,191 @@
+package spirv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// encodeString packs s into words as a nul-terminated literal string.
+func encodeString(s string) []uint32 {
+	b := append([]byte(s), 0)
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+
+	words := make([]uint32, len(b)/4)
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint32(b[i*4:])
+	}
+	return words
+}
+
+// assemble builds a module from instructions, each given as an opcode followed by its operands.
+func assemble(order binary.ByteOrder, bound uint32, instructions ...[]uint32) []byte {
+	words := []uint32{Magic, 0x00010000, 0, bound, 0}
+	for _, inst := range instructions {
+		words = append(words, uint32(len(inst))<<16|inst[0])
+		words = append(words, inst[1:]...)
+	}
+
+	buf := &bytes.Buffer{}
+	binary.Write(buf, order, words)
+	return buf.Bytes()
+}
+
+// op returns an instruction with the given opcode and operands, where operands may be ints or strings.
+func op(opcode Opcode, operands ...interface{}) []uint32 {
+	inst := []uint32{uint32(opcode)}
+	for _, o := range operands {
+		switch v := o.(type) {
+		case string:
+			inst = append(inst, encodeString(v)...)
+		case int:
+			inst = append(inst, uint32(v))
+		}
+	}
+	return inst
+}
+
+func testModule(order binary.ByteOrder) []byte {
+	return assemble(order, 16,
+		op(17, 1),                              // OpCapability Shader
+		op(OpExtInstImport, 1, "GLSL.std.450"), // %1
+		op(14, 0, 1),                           // OpMemoryModel Logical GLSL450
+		op(OpEntryPoint, 4, 4, "main", 9, 15),
+		op(16, 4, 7), // OpExecutionMode %4 OriginUpperLeft
+		op(OpName, 4, "main"),
+		op(OpName, 9, "outColor"),
+		op(OpName, 12, "Params"),
+		op(OpDecorate, 9, 30, 0),   // Location 0
+		op(OpDecorate, 13, 34, 0),  // DescriptorSet 0
+		op(OpDecorate, 13, 33, 1),  // Binding 1
+		op(OpDecorate, 15, 11, 15), // BuiltIn FragCoord
+		op(OpMemberDecorate, 12, 0, 35, 0),
+		op(19, 2),                   // %2 = OpTypeVoid
+		op(22, 7, 32),               // %7 = OpTypeFloat 32
+		op(23, 8, 7, 4),             // %8 = OpTypeVector %7 4
+		op(OpTypePointer, 10, 3, 8), // Output
+		op(OpVariable, 10, 9, 3),
+		op(30, 12, 8),                // %12 = OpTypeStruct %8
+		op(OpTypePointer, 14, 2, 12), // Uniform
+		op(OpVariable, 14, 13, 2),
+		op(OpTypePointer, 6, 1, 8), // Input
+		op(OpVariable, 6, 15, 1),
+		op(33, 3, 2),       // %3 = OpTypeFunction %2
+		op(54, 2, 4, 0, 3), // %4 = OpFunction %2 None %3
+		op(248, 5),         // %5 = OpLabel
+		op(253),            // OpReturn
+		op(56),             // OpFunctionEnd
+	)
+}
+
+func TestParse(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		m, err := Parse(bytes.NewReader(testModule(order)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if m.ByteOrder != order {
+			t.Fatalf("Got byte order %v, expected %v", m.ByteOrder, order)
+		}
+		if m.VersionString() != "1.0" || m.Bound != 16 {
+			t.Fatalf("Got version %s and bound %d, expected 1.0 and 16", m.VersionString(), m.Bound)
+		}
+		if len(m.Instructions) != 28 {
+			t.Fatalf("Got %d instructions, expected 28", len(m.Instructions))
+		}
+
+		points := m.EntryPoints()
+		if len(points) != 1 {
+			t.Fatalf("Got %d entry points, expected 1", len(points))
+		}
+		p := points[0]
+		if p.Model.String() != "Fragment" || p.Function != 4 || p.Name != "main" || len(p.Interface) != 2 || p.Interface[0] != 9 {
+			t.Fatalf("Got entry point %+v", p)
+		}
+	}
+}
+
+func TestVariables(t *testing.T) {
+	m, err := Parse(bytes.NewReader(testModule(binary.LittleEndian)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vars := m.Variables()
+	if len(vars) != 3 {
+		t.Fatalf("Got %d variables, expected 3", len(vars))
+	}
+
+	if v := vars[0]; v.ID != 9 || v.Name != "outColor" || v.StorageClass != StorageClassOutput {
+		t.Fatalf("Got %+v, expected outColor", v)
+	}
+	if loc, ok := vars[0].Decoration(DecorationLocation); !ok || loc != 0 {
+		t.Fatalf("Got location %d, expected 0", loc)
+	}
+
+	ubo := vars[1]
+	if ubo.ID != 13 || ubo.Name != "Params" || ubo.StorageClass != StorageClassUniform {
+		t.Fatalf("Got %+v, expected Params", ubo)
+	}
+	set, setOK := ubo.Decoration(DecorationDescriptorSet)
+	binding, bindingOK := ubo.Decoration(DecorationBinding)
+	if !setOK || !bindingOK || set != 0 || binding != 1 {
+		t.Fatalf("Got set %d binding %d, expected set 0 binding 1", set, binding)
+	}
+
+	if b, ok := vars[2].Decoration(DecorationBuiltIn); !ok || BuiltIn(b).String() != "FragCoord" {
+		t.Fatalf("Got built-in %d, expected FragCoord", b)
+	}
+}
+
+func TestDisassemble(t *testing.T) {
+	m, err := Parse(bytes.NewReader(testModule(binary.LittleEndian)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &strings.Builder{}
+	if err := Disassemble(buf, m); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	expected := []string{
+		"; Version: 1.0\n",
+		"               OpCapability Shader\n",
+		"          %1 = OpExtInstImport \"GLSL.std.450\"\n",
+		"               OpMemoryModel Logical GLSL450\n",
+		"               OpEntryPoint Fragment %4 \"main\" %9 %15\n",
+		"               OpExecutionMode %4 OriginUpperLeft\n",
+		"               OpDecorate %13 DescriptorSet 0\n",
+		"               OpDecorate %15 BuiltIn FragCoord\n",
+		"               OpMemberDecorate %12 0 Offset 0\n",
+		"         %10 = OpTypePointer Output %8\n",
+		"          %9 = OpVariable %10 Output\n",
+		"          %4 = OpFunction %2 0 %3\n",
+		"               OpFunctionEnd\n",
+	}
+	for _, line := range expected {
+		if !strings.Contains(out, line) {
+			t.Fatalf("Disassembly does not contain %q:\n%s", line, out)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := Parse(bytes.NewReader([]byte("not a shader module"))); err == nil {
+		t.Fatal("Parsing garbage succeeded")
+	}
+
+	// Replace OpReturn and OpFunctionEnd with an instruction which claims to be two words long
+	truncated := testModule(binary.LittleEndian)
+	truncated = truncated[:len(truncated)-8]
+	truncated = append(truncated, 0, 0, 2, 0)
+	if _, err := Parse(bytes.NewReader(truncated)); err == nil {
+		t.Fatal("Parsing a truncated instruction succeeded")
+	}
+}